@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// bufExtensionField is the field number of the extension buf images add to
+// their file descriptors; its is_import field (1) marks the imported files
+const bufExtensionField protowire.Number = 8042
+
+// stringList collects the values of a repeatable command line flag
+type stringList []string
+
+// String implements flag.Value
+func (l *stringList) String() string {
+	return strings.Join(*l, " ")
+}
+
+// Set implements flag.Value
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// runGen generates a collection from a descriptor set or buf image and writes
+// it to a directory, without protoc:
+//
+//	protoc-gen-bruno gen --descriptor-set image.binpb --out ./collection
+func runGen(args []string) error {
+	flags := flag.NewFlagSet("gen", flag.ContinueOnError)
+	descriptorSet := flags.String("descriptor-set", "", "FileDescriptorSet or buf image to generate from (buf build -o image.binpb, or protoc --descriptor_set_out --include_imports)")
+	out := flags.String("out", "", "Directory the collection is written to")
+	var opts, files stringList
+	flags.Var(&opts, "opt", `Plugin option as name=value, like "mode=http"; repeatable`)
+	flags.Var(&files, "file", "Proto file to generate, as its path in the descriptor set (default: the files that are not imports); repeatable")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *descriptorSet == "" || *out == "" {
+		return errors.New("gen needs --descriptor-set and --out")
+	}
+
+	content, err := os.ReadFile(*descriptorSet)
+	if err != nil {
+		return err
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(content, set); err != nil {
+		return fmt.Errorf("reading %s: %v", *descriptorSet, err)
+	}
+
+	req := &pluginpb.CodeGeneratorRequest{ProtoFile: set.File, FileToGenerate: files}
+	if len(files) == 0 {
+		for _, f := range set.File {
+			if !isImageImport(f) {
+				req.FileToGenerate = append(req.FileToGenerate, f.GetName())
+			}
+		}
+	}
+
+	// Existing files are read from the output directory by write_mode
+	params := append([]string{"out_dir=" + *out}, opts...)
+	g, err := brunogen.New(brunogen.Options{Params: params, Version: pluginVersion(), Log: os.Stderr})
+	if err != nil {
+		return err
+	}
+	resp, err := g.Run(req)
+	if err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return errors.New(resp.GetError())
+	}
+
+	for _, file := range resp.File {
+		name := filepath.Join(*out, filepath.FromSlash(file.GetName()))
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(name, []byte(file.GetContent()), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isImageImport reports whether a file of a buf image was only included as an
+// import. Plain descriptor sets do not mark imports, so all their files count.
+func isImageImport(file *descriptorpb.FileDescriptorProto) bool {
+	b := file.ProtoReflect().GetUnknown()
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return false
+		}
+		b = b[n:]
+		if num == bufExtensionField && typ == protowire.BytesType {
+			ext, _ := protowire.ConsumeBytes(b)
+			for len(ext) > 0 {
+				extNum, extTyp, m := protowire.ConsumeTag(ext)
+				if m < 0 {
+					return false
+				}
+				ext = ext[m:]
+				if extNum == 1 && extTyp == protowire.VarintType {
+					isImport, _ := protowire.ConsumeVarint(ext)
+					return isImport != 0
+				}
+				m = protowire.ConsumeFieldValue(extNum, extTyp, ext)
+				if m < 0 {
+					return false
+				}
+				ext = ext[m:]
+			}
+		}
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		if n < 0 {
+			return false
+		}
+		b = b[n:]
+	}
+	return false
+}
@@ -3,6 +3,7 @@ module github.com/eugene-bert/protoc-gen-bruno
 go 1.25.1
 
 require (
+	github.com/grpc-ecosystem/grpc-gateway/v2 v2.27.3
 	google.golang.org/genproto/googleapis/api v0.0.0-20251007200510-49b9836ed3ff
 	google.golang.org/protobuf v1.36.10
 )
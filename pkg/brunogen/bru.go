@@ -0,0 +1,129 @@
+package brunogen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// bruWriter writes a .bru file block by block. It separates blocks with a blank
+// line and escapes what Bruno would misread: dictionary keys with colons,
+// whitespace, braces or a leading ~, multi-line values, and text lines that
+// would close their block.
+type bruWriter struct {
+	g      *protogen.GeneratedFile
+	blocks int
+	block  string
+	// insertionPoints ends each block with an insertion point
+	insertionPoints bool
+}
+
+// newBruWriter returns a writer of .bru blocks to a generated file
+func (s *state) newBruWriter(g *protogen.GeneratedFile) *bruWriter {
+	return &bruWriter{g: g, insertionPoints: s.insertionPoints}
+}
+
+// open starts a block, e.g. "headers {"
+func (w *bruWriter) open(name string) {
+	if w.blocks > 0 {
+		w.g.P("")
+	}
+	w.blocks++
+	w.block = name
+	w.g.P(name, " {")
+}
+
+// close ends the current block
+func (w *bruWriter) close() {
+	if w.insertionPoints {
+		w.insertionPoint()
+	}
+	w.g.P("}")
+}
+
+// insertionPoint marks the end of the current block for companion plugins,
+// which add lines to it with a CodeGeneratorResponse insertion point named
+// after the block, e.g. "script:pre-request". The marker line is disabled or
+// commented out so Bruno ignores it.
+func (w *bruWriter) insertionPoint() {
+	marker := "@@protoc_insertion_point(" + w.block + ")"
+	if comment, ok := markedBlocks[w.block]; ok {
+		w.g.P("  ", comment[0], marker, comment[1])
+	} else if w.block == "headers" || w.block == "metadata" {
+		w.g.P("  ~", marker, ":")
+	}
+}
+
+// entry writes a "key: value" entry of a dictionary block. The parts of the
+// value are concatenated like the arguments of protogen's P. Values spanning
+// several lines are written between triple single quotes, as Bruno expects.
+func (w *bruWriter) entry(key string, value ...any) {
+	var s strings.Builder
+	for _, part := range value {
+		fmt.Fprint(&s, part)
+	}
+	if !strings.Contains(s.String(), "\n") {
+		w.g.P("  ", bruKey(key), ": ", s.String())
+		return
+	}
+	w.g.P("  ", bruKey(key), ": '''")
+	for _, line := range strings.Split(s.String(), "\n") {
+		w.g.P(indentLine("    ", line))
+	}
+	w.g.P("  '''")
+}
+
+// list writes a list entry of a dictionary block, like the tags of meta
+func (w *bruWriter) list(key string, items []string) {
+	w.g.P("  ", bruKey(key), ": [")
+	for _, item := range items {
+		w.g.P("    ", item)
+	}
+	w.g.P("  ]")
+}
+
+// text writes lines of a text block, such as docs, a body or a script. Every
+// line is indented, so none can close the block, whatever it starts with.
+func (w *bruWriter) text(lines ...string) {
+	for _, line := range lines {
+		for _, l := range strings.Split(line, "\n") {
+			w.g.P(indentLine("  ", l))
+		}
+	}
+}
+
+// listBlock writes a list block, like "vars:secret [", with one item per line
+func (w *bruWriter) listBlock(name string, items []string) {
+	if w.blocks > 0 {
+		w.g.P("")
+	}
+	w.blocks++
+	w.g.P(name, " [")
+	for i, item := range items {
+		if i < len(items)-1 {
+			item += ","
+		}
+		w.g.P("  ", item)
+	}
+	w.g.P("]")
+}
+
+// bruKey quotes the dictionary keys Bruno would split or misread: empty keys,
+// keys with colons, whitespace, quotes or braces, and keys starting with ~,
+// which would disable their entry
+func bruKey(key string) string {
+	if key != "" && !strings.HasPrefix(key, "~") && !strings.ContainsAny(key, ":\"{}[] \t\r\n") {
+		return key
+	}
+	return strconv.Quote(key)
+}
+
+// indentLine indents a line of a block, leaving blank lines empty
+func indentLine(indent string, line string) string {
+	if strings.TrimSpace(line) == "" {
+		return ""
+	}
+	return indent + line
+}
@@ -0,0 +1,128 @@
+package brunogen
+
+import "testing"
+
+// writeBru returns the content written by a function of a bruWriter
+func writeBru(t *testing.T, insertionPoints bool, write func(w *bruWriter)) string {
+	t.Helper()
+	s := newState()
+	s.insertionPoints = insertionPoints
+	w := s.newBruWriter(testPlugin(t).NewGeneratedFile("Test.bru", ""))
+	write(w)
+	content, err := w.g.Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestBruKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "X-Tenant", want: "X-Tenant"},
+		{key: "user.id", want: "user.id"},
+		{key: "", want: `""`},
+		{key: "a:b", want: `"a:b"`},
+		{key: "two words", want: `"two words"`},
+		{key: "tab\there", want: `"tab\there"`},
+		{key: `say "hi"`, want: `"say \"hi\""`},
+		{key: "{id}", want: `"{id}"`},
+		{key: "items[0]", want: `"items[0]"`},
+		{key: "~disabled", want: `"~disabled"`},
+		{key: "not~disabled", want: "not~disabled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := bruKey(tt.key); got != tt.want {
+				t.Errorf("bruKey(%q) = %s, want %s", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBruWriter(t *testing.T) {
+	tests := []struct {
+		name            string
+		insertionPoints bool
+		write           func(w *bruWriter)
+		want            string
+	}{
+		{
+			name: "blocks are separated by a blank line",
+			write: func(w *bruWriter) {
+				w.open("meta")
+				w.entry("name", "Get User")
+				w.entry("seq", 1)
+				w.close()
+				w.open("get")
+				w.entry("url", "{{base_url}}/v1/users/", "{{id}}")
+				w.close()
+			},
+			want: "meta {\n  name: Get User\n  seq: 1\n}\n\nget {\n  url: {{base_url}}/v1/users/{{id}}\n}\n",
+		},
+		{
+			name: "multiline values are written between triple quotes",
+			write: func(w *bruWriter) {
+				w.open("vars:pre-request")
+				w.entry("query", "{\n  user(id: 1) {\n\n    name\n  }\n}")
+				w.close()
+			},
+			want: "vars:pre-request {\n  query: '''\n    {\n      user(id: 1) {\n\n        name\n      }\n    }\n  '''\n}\n",
+		},
+		{
+			name: "keys that need quoting",
+			write: func(w *bruWriter) {
+				w.open("headers")
+				w.entry("X Custom", "a")
+				w.entry("~X-Off", "b")
+				w.entry("a:b", "c")
+				w.close()
+			},
+			want: "headers {\n  \"X Custom\": a\n  \"~X-Off\": b\n  \"a:b\": c\n}\n",
+		},
+		{
+			name: "text lines starting with } or ~ stay in the block",
+			write: func(w *bruWriter) {
+				w.open("docs")
+				w.text("}", "~ not an entry\n}\n\ndone")
+				w.close()
+			},
+			want: "docs {\n  }\n  ~ not an entry\n  }\n\n  done\n}\n",
+		},
+		{
+			name: "lists",
+			write: func(w *bruWriter) {
+				w.open("meta")
+				w.list("tags", []string{"users", "v1"})
+				w.close()
+				w.listBlock("vars:secret", []string{"token", "api_key"})
+			},
+			want: "meta {\n  tags: [\n    users\n    v1\n  ]\n}\n\nvars:secret [\n  token,\n  api_key\n]\n",
+		},
+		{
+			name:            "insertion points",
+			insertionPoints: true,
+			write: func(w *bruWriter) {
+				w.open("headers")
+				w.entry("X-Tenant", "acme")
+				w.close()
+				w.open("docs")
+				w.text("Returns a user.")
+				w.close()
+				w.open("get")
+				w.close()
+			},
+			want: "headers {\n  X-Tenant: acme\n  ~@@protoc_insertion_point(headers):\n}\n\n" +
+				"docs {\n  Returns a user.\n  <!-- @@protoc_insertion_point(docs) -->\n}\n\nget {\n}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := writeBru(t, tt.insertionPoints, tt.write); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
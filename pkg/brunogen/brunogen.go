@@ -0,0 +1,2347 @@
+package brunogen
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/envgen"
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/examples"
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/grpcgen"
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/httpgen"
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/naming"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-openapiv2/options"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// generationMode selects the requests generated: HTTP, gRPC or both. It is
+// passed down to the generators rather than kept with the other options.
+type generationMode string
+
+const (
+	modeAll  generationMode = "all"
+	modeHTTP generationMode = "http"
+	modeGRPC generationMode = "grpc"
+)
+
+// http reports whether HTTP requests are generated
+func (m generationMode) http() bool {
+	return m == modeAll || m == modeHTTP
+}
+
+// grpc reports whether gRPC requests are generated
+func (m generationMode) grpc() bool {
+	return m == modeAll || m == modeGRPC
+}
+
+// Supported Bruno collection schema versions
+const (
+	brunoVersion1 = "1"
+	brunoVersion2 = "2"
+)
+
+// Supported orders of requests within a folder
+const (
+	requestOrderWorkflow    = "workflow"
+	requestOrderDeclaration = "declaration"
+	requestOrderName        = "name"
+)
+
+// Supported ways of splitting the output into collections
+const (
+	collectionPerAll     = "all"
+	collectionPerPackage = "package"
+	collectionPerService = "service"
+	collectionPerFile    = "file"
+)
+
+// Supported case conventions of generated file and folder names
+const (
+	fileCasePascal = "pascal"
+	fileCaseKebab  = "kebab"
+	fileCaseSnake  = "snake"
+)
+
+// Supported folder layouts
+const (
+	layoutService  = "service"
+	layoutPackage  = "package"
+	layoutVersion  = "version"
+	layoutResource = "resource"
+	layoutTag      = "tag"
+)
+
+// state holds the options of a generation run, parsed from the plugin
+// options, and what it collects while generating. Each run of a Generator
+// has its own, so Generators may be used concurrently.
+type state struct {
+	collectionAuthMode string
+	collectionTokenVar string
+	brunoVersion       string
+	splitBasePath      bool
+	varPrefix          string
+	collectionPer      string
+	collectionMap      collectionMapList
+	includeServices    patternList
+	excludeServices    patternList
+	includeMethods     patternList
+	excludeMethods     patternList
+	layout             string
+	fileCaseStyle      string
+	collectionReadme   bool
+	unifiedFolders     bool
+	outPrefix          string
+	includeImports     bool
+	writeMode          string
+	outDir             string
+	noCollectionConfig bool
+	pathRewrites       rewriteRuleList
+	templateDir        string
+	verify             bool
+	dryRun             bool
+	outputFormat       string
+	openAPISpec        bool
+	invalidHTTPRules   string
+	hashFiles          bool
+	reportChangedFiles bool
+	exampleLimits      examples.Limits
+	insertionPoints    bool
+	manifest           bool
+	stats              bool
+	globalEnvironments bool
+	requestAuthMode    string
+	apiKeyName         string
+	apiKeyPlacement    string
+	oauth2TokenURL     string
+	oauth2Scopes       string
+	oauth2AuthorizeURL string
+	oauth2CallbackURL  string
+	oauth2PKCE         bool
+	inheritRequestAuth bool
+	loginPath          string
+	loginMethod        string
+	loginTokenField    string
+	openAPISecurity    bool
+	methodAuthModes    map[string]bool
+	mtlsEnabled        bool
+	mtlsCertPath       string
+	mtlsKeyPath        string
+	hmacSignAll        bool
+	hmacHeader         string
+	hmacSigningUsed    bool
+	idempotencyKey     bool
+	idempotencyHeader  string
+	csrfEndpoint       string
+	csrfCookie         string
+	csrfHeader         string
+	secretsMode        string
+	devJWT             bool
+	devJWTClaims       string
+	idTokenCommand     string
+	idTokenURL         string
+	refreshPath        string
+	refreshTokenField  string
+	requestOrder       string
+	preRequestInline   string
+	scriptLibrary      bool
+	traceContext       bool
+	userAgent          string
+	generatorVersion   string
+	curlBaseURL        string
+	nameTemplate       string
+	correlationHeader  string
+	requestTimeout     int
+	followRedirects    string
+	maxRedirects       int
+	maxRetries         int
+	customHeaders      headerList
+	envHeaders         envHeaderList
+	grpcMetadata       metadataList
+	assertions         bool
+	schemaTests        bool
+	validationTests    bool
+	conditionalReads   bool
+	assertStatus       string
+	maxResponseTime    int
+	// maxCollectionRequests is the size above which collections are split, if any
+	maxCollectionRequests int
+	// debugLog receives the generation trace when debug=true, and is nil otherwise
+	debugLog io.Writer
+	// templates holds the templates loaded from template_dir, by kind
+	templates map[string]*template.Template
+	// Per-environment auth, applied by a collection pre-request script since Bruno
+	// auth blocks cannot vary with the selected environment
+	envAuthModes   map[string]string
+	envAuthDefault string
+
+	// folderOrder lists the folders placed first in the collection sidebar
+	folderOrder []string
+	// folderSeqs counts the unlisted folders written to each collection
+	folderSeqs map[string]int
+	// collidingServices holds the services sharing a folder name with a service
+	// of another package in the same collection
+	collidingServices map[protoreflect.FullName]bool
+	// groupFolders holds the folder of each method grouped across services
+	groupFolders map[protoreflect.FullName]groupFolder
+	// groupMembers holds the methods sharing the group folder of each method
+	groupMembers map[protoreflect.FullName][]*protogen.Method
+	// writtenFolders holds the folders whose folder.bru was written, by path
+	writtenFolders map[string]bool
+	// collectionSplits holds the sub-collection of the services of oversized collections
+	collectionSplits map[protoreflect.FullName]collectionSplit
+	// qualifiedCollections holds the services whose collection_per=service
+	// collection is named after their package too, sharing their name with a
+	// service of another package
+	qualifiedCollections map[protoreflect.FullName]bool
+	// Security definitions and default requirements collected from
+	// openapiv2_swagger file options
+	openAPISchemes         map[string]*options.SecurityScheme
+	openAPIDefaultSecurity []*options.SecurityRequirement
+	// manifestRequests holds the requests generated in each collection, by prefix
+	manifestRequests map[string][]manifestRequest
+	// templateRequests holds the requests generated for templates, by file
+	templateRequests map[string]manifestRequest
+	// requestJobs holds the writers of the request files created so far, in
+	// creation order
+	requestJobs []func() error
+	// skippedMethods lists the requests skipped so far, in generation order
+	skippedMethods []skippedMethod
+}
+
+// newState returns the state of a run with the default options
+func newState() *state {
+	return &state{
+		brunoVersion:         brunoVersion1,
+		collectionPer:        collectionPerAll,
+		layout:               layoutService,
+		fileCaseStyle:        fileCasePascal,
+		writeMode:            writeModeOverwrite,
+		outputFormat:         formatBruno,
+		invalidHTTPRules:     invalidRulesSkip,
+		exampleLimits:        examples.DefaultLimits,
+		apiKeyName:           "X-Api-Key",
+		apiKeyPlacement:      "header",
+		loginMethod:          "post",
+		loginTokenField:      "token",
+		methodAuthModes:      map[string]bool{},
+		mtlsCertPath:         "certs/client.crt",
+		mtlsKeyPath:          "certs/client.key",
+		hmacHeader:           "X-Signature",
+		idempotencyHeader:    "Idempotency-Key",
+		csrfHeader:           "X-CSRF-Token",
+		devJWTClaims:         "sub=dev-user",
+		refreshTokenField:    "refresh_token",
+		requestOrder:         requestOrderWorkflow,
+		generatorVersion:     "dev",
+		curlBaseURL:          "http://localhost:8080",
+		correlationHeader:    "X-Correlation-Id",
+		requestTimeout:       -1,
+		maxRedirects:         -1,
+		assertStatus:         "200",
+		maxResponseTime:      -1,
+		folderSeqs:           map[string]int{},
+		collidingServices:    map[protoreflect.FullName]bool{},
+		groupFolders:         map[protoreflect.FullName]groupFolder{},
+		groupMembers:         map[protoreflect.FullName][]*protogen.Method{},
+		writtenFolders:       map[string]bool{},
+		collectionSplits:     map[protoreflect.FullName]collectionSplit{},
+		qualifiedCollections: map[protoreflect.FullName]bool{},
+		envAuthDefault:       "none",
+		manifestRequests:     map[string][]manifestRequest{},
+		templateRequests:     map[string]manifestRequest{},
+	}
+}
+
+// Options configures a Generator
+type Options struct {
+	// Params are plugin options as name=value pairs, as given to protoc with
+	// --bruno_opt, e.g. "mode=http" or "collection_name=My API". They are set
+	// after the typed options below, which are left empty to keep the defaults.
+	Params []string
+	// Mode is the requests generated: all, http or grpc
+	Mode string
+	// Format is the output format, like bruno or http
+	Format string
+	// BrunoVersion is the Bruno collection schema version: 1 or 2
+	BrunoVersion string
+	// CollectionName names the collection, or is a template of the collection
+	// names with {package}, {version} and {service} placeholders
+	CollectionName string
+	// CollectionPer splits the output into collections: all, package, service
+	// or file
+	CollectionPer string
+	// Layout is the folder layout, like service or package
+	Layout string
+	// OutDir is the output directory given to protoc, so existing files can be
+	// read
+	OutDir string
+	// OutPrefix is the directory prepended to all generated paths
+	OutPrefix string
+	// WriteMode is how existing files are written over: overwrite,
+	// skip-existing or merge
+	WriteMode string
+	// IncludeServices and ExcludeServices select the services by full name,
+	// with regular expressions
+	IncludeServices, ExcludeServices []string
+	// IncludeMethods and ExcludeMethods select the methods by full name
+	// (package.Service/Method), with regular expressions
+	IncludeMethods, ExcludeMethods []string
+	// Headers are added to every HTTP request, as "Name: value"
+	Headers []string
+	// Version is the plugin version sent in the default User-Agent header
+	Version string
+	// Log receives the summary of skipped requests, like the methods without
+	// an HTTP rule; nil discards it
+	Log io.Writer
+}
+
+// Generator generates Bruno collections from proto files, like the
+// protoc-gen-bruno plugin does, so tools can embed it without running protoc.
+type Generator struct {
+	flags    flag.FlagSet
+	generate func(gen *protogen.Plugin) error
+	// state is the state of the last run
+	state   *state
+	log     io.Writer
+	version string
+	// params are the options set so far, replayed for each profile
+	params         []string
+	profiles       []profile
+	profileOptions []profileOption
+}
+
+// New returns a Generator configured with the plugin options
+func New(opts Options) (*Generator, error) {
+	g := &Generator{log: opts.Log}
+	if opts.Version == "" {
+		opts.Version = "dev"
+	}
+	g.version = opts.Version
+
+	flags := &g.flags
+	var modeFlag string
+	var singleCollectionFlag string
+	var collectionPerFlag string
+	var collectionReadmeFlag string
+	var unifiedFoldersFlag string
+	var outPrefixFlag string
+	var includeImportsFlag string
+	var writeModeFlag string
+	var verifyFlag string
+	var dryRunFlag string
+	var formatFlag string
+	var openAPIFlag string
+	var invalidHTTPRulesFlag string
+	var hashesFlag string
+	var reportChangesFlag string
+	var debugFlag string
+	var insertionPointsFlag string
+	var noCollectionConfigFlag string
+	var manifestFlag string
+	var statsFlag string
+	var maxCollectionRequestsFlag string
+	var maxExampleDepthFlag, maxExampleSizeFlag string
+	var collectionNameFlag string
+	var devURL, stgURL, prdURL, localURL string
+	var grpcDevURL, grpcStgURL, grpcPrdURL, grpcLocalURL string
+	var protoRootFlag string
+	var preRequestScriptPath string
+	var postRequestScriptPath string
+	var authMode string
+	var authTokenVar string
+	var brunoVersionFlag string
+	var splitBasePathFlag string
+	var varPrefixFlag string
+	var globalEnvironmentsFlag string
+	var requestAuthFlag string
+	var apiKeyNameFlag string
+	var apiKeyPlacementFlag string
+	var oauth2TokenURLFlag string
+	var oauth2ScopesFlag string
+	var oauth2AuthorizeURLFlag string
+	var oauth2CallbackURLFlag string
+	var oauth2PKCEFlag string
+	var authLevelFlag string
+	var loginPathFlag string
+	var loginMethodFlag string
+	var loginTokenFieldFlag string
+	var openAPISecurityFlag string
+	var mtlsFlag string
+	var mtlsCertFlag string
+	var mtlsKeyFlag string
+	var hmacSignFlag string
+	var hmacHeaderFlag string
+	var idempotencyKeyFlag string
+	var idempotencyHeaderFlag string
+	var csrfEndpointFlag string
+	var csrfCookieFlag string
+	var csrfHeaderFlag string
+	var secretsFlag string
+	var devJWTFlag string
+	var envAuthFlag string
+	var idTokenCommandFlag string
+	var idTokenURLFlag string
+	var refreshPathFlag string
+	var requestOrderFlag string
+	var layoutFlag string
+	var fileCaseFlag string
+	var scriptLibraryFlag string
+	var traceContextFlag string
+	var userAgentFlag string
+	var correlationHeaderFlag string
+	var timeoutFlag string
+	var followRedirectsFlag string
+	var maxRedirectsFlag string
+	var maxRetriesFlag string
+	var assertionsFlag string
+	var schemaTestsFlag string
+	var validationTestsFlag string
+	var folderOrderFlag string
+	var conditionalRequestsFlag string
+	var assertStatusFlag string
+	var maxResponseTimeFlag string
+	var refreshTokenFieldFlag string
+	var devJWTClaimsFlag string
+	var collectionMap collectionMapList
+	var includeServices, excludeServices patternList
+	var includeMethods, excludeMethods patternList
+	var pathRewrites rewriteRuleList
+	var templateDir, outDir string
+	var preRequestInline string
+	var customHeaders headerList
+	var envHeaders envHeaderList
+	var grpcMetadata metadataList
+	var nameTemplate string
+
+	flags.StringVar(&modeFlag, "mode", "all", "Generation mode: all, http, or grpc")
+	flags.StringVar(&singleCollectionFlag, "single_collection", "true", "Generate a single collection for all modules")
+	flags.Var(&collectionMap, "collection_map", `Group the packages under a prefix into a named collection, as "package.prefix=Collection Name"; repeatable`)
+	flags.StringVar(&collectionPerFlag, "collection_per", "", "Split the output into collections: all, package, service or file (default: all, or package with single_collection=false)")
+	flags.StringVar(&collectionReadmeFlag, "collection_readme", "false", "Generate a README.md summarizing each collection")
+	flags.Var(&includeServices, "include_services", "Only generate services whose full name (package.Service) matches this regular expression; repeatable")
+	flags.Var(&excludeServices, "exclude_services", "Skip services whose full name (package.Service) matches this regular expression; repeatable")
+	flags.Var(&includeMethods, "include_methods", "Only generate methods whose full name (package.Service/Method) matches this regular expression; repeatable")
+	flags.Var(&excludeMethods, "exclude_methods", "Skip methods whose full name (package.Service/Method) matches this regular expression; repeatable")
+	flags.StringVar(&maxCollectionRequestsFlag, "max_collection_requests", "0", "Split collections with more requests than this into sub-collections per package, then per service (0 disables)")
+	flags.StringVar(&formatFlag, "format", formatBruno, "Output format: bruno (a Bruno collection), http (a .http file per service for the VS Code REST Client), hoppscotch (a Hoppscotch collection), k6 (a k6 load test script), hurl (a .hurl file per request) or scripts (a curl or grpcurl shell script per request)")
+	flags.StringVar(&openAPIFlag, "openapi", "false", "Generate an OpenAPI 3.1 openapi.yaml per collection, describing the same paths, parameters and example bodies as its HTTP requests")
+	flags.StringVar(&invalidHTTPRulesFlag, "invalid_http_rules", invalidRulesSkip, "How to handle google.api.http rules with an empty path, an unsupported pattern or an unknown body field: skip, fail, or placeholder (a request with a warning in its docs)")
+	flags.StringVar(&maxExampleDepthFlag, "max_example_depth", strconv.Itoa(examples.DefaultLimits.Depth), "Nesting depth down to which example bodies set every field; deeper, only required fields are set")
+	flags.StringVar(&maxExampleSizeFlag, "max_example_size", strconv.Itoa(examples.DefaultLimits.Size), "Size in bytes past which example bodies are cut short, with a _truncated field marking the cut (0 disables)")
+	flags.StringVar(&manifestFlag, "manifest", "false", "Generate a manifest.json listing the requests of each collection")
+	flags.StringVar(&statsFlag, "stats", "false", "Generate a stats.json counting the HTTP and gRPC requests of each service and listing the skipped methods")
+	flags.Var(&pathRewrites, "rewrite_path", "Rewrite generated file paths as pattern=>replacement, with a regular expression pattern; repeatable, applied in order")
+	flags.StringVar(&templateDir, "template_dir", "", "Directory of request.bru.tmpl, folder.bru.tmpl, collection.bru.tmpl, environment.bru.tmpl and bruno.json.tmpl templates rendering the generated files, relative to where protoc runs (optional)")
+	flags.StringVar(&noCollectionConfigFlag, "no_collection_config", "false", "Generate only the request folders, without bruno.json, collection.bru, environments or other collection files, to drop them into an existing collection")
+	flags.StringVar(&writeModeFlag, "write_mode", "overwrite", "How to write over existing files: overwrite, skip-existing or merge (keep manual edits of .bru files); needs out_dir")
+	flags.StringVar(&verifyFlag, "verify", "false", "Compare the generated files with the ones in out_dir instead of writing them, failing when they are out of date")
+	flags.StringVar(&dryRunFlag, "dry_run", "false", "Write only a dry-run.json listing the files and requests that would be generated, with their URLs")
+	flags.StringVar(&hashesFlag, "hashes", "false", "Generate a hashes.json with the SHA-256 of every generated file")
+	flags.StringVar(&reportChangesFlag, "report_changes", "false", "Write the files added, changed and removed since the hashes.json in out_dir to stderr; implies hashes")
+	flags.StringVar(&insertionPointsFlag, "insertion_points", "false", "Mark the headers, metadata, script, tests and docs blocks with protoc insertion points, so companion plugins can add to them")
+	flags.StringVar(&debugFlag, "debug", "false", "Write a trace of the generation to stderr: files considered, methods matched, options resolved and where each field goes")
+	flags.StringVar(&outDir, "out_dir", "", "The output directory given to protoc, relative to where it runs, so existing files can be read (e.g., bruno/collections)")
+	flags.StringVar(&includeImportsFlag, "include_imports", "false", "Also generate the services of the files directly imported by the files to generate, except google/ files")
+	flags.StringVar(&outPrefixFlag, "out_prefix", "", "Directory prepended to all generated paths, relative to the output directory (e.g., bruno/collections)")
+	flags.StringVar(&unifiedFoldersFlag, "unified_folders", "false", "Put the HTTP and gRPC requests of a method in the same folder, as <Method>.http.bru and <Method>.grpc.bru")
+	flags.StringVar(&collectionNameFlag, "collection_name", "", "Custom collection name, or a template with {package}, {version} and {service} placeholders (defaults to auto-generated from services)")
+	flags.StringVar(&devURL, "dev_url", "", "Development environment base URL (e.g., https://api.dev.example.com/service)")
+	flags.StringVar(&stgURL, "stg_url", "", "Staging environment base URL")
+	flags.StringVar(&prdURL, "prd_url", "", "Production environment base URL")
+	flags.StringVar(&localURL, "local_url", "", "Local environment base URL (defaults to http://localhost:8080)")
+	flags.StringVar(&grpcDevURL, "grpc_dev_url", "", "Development gRPC URL (e.g., api.dev.example.com:443) - overrides auto-generated from dev_url")
+	flags.StringVar(&grpcStgURL, "grpc_stg_url", "", "Staging gRPC URL - overrides auto-generated from stg_url")
+	flags.StringVar(&grpcPrdURL, "grpc_prd_url", "", "Production gRPC URL - overrides auto-generated from prd_url")
+	flags.StringVar(&grpcLocalURL, "grpc_local_url", "", "Local gRPC URL (e.g., localhost:50051) - overrides auto-generated from local_url")
+	flags.StringVar(&protoRootFlag, "proto_root", "../../proto", "Path to proto files root directory relative to bruno/collections (e.g., ../../api/proto/src)")
+	flags.StringVar(&preRequestScriptPath, "pre_request_script", "", "Path to JavaScript file containing collection-level pre-request script")
+	flags.StringVar(&preRequestInline, "pre_request_script_inline", "", "JavaScript statements added to the collection-level pre-request script (optional)")
+	flags.StringVar(&postRequestScriptPath, "post_request_script", "", "Path to JavaScript file containing collection-level post-request script")
+	flags.StringVar(&authMode, "auth_mode", "", "Authentication mode for collection: bearer, basic, apikey, or awsv4 (optional)")
+	flags.StringVar(&requestAuthFlag, "auth", "", "Request authentication: bearer, apikey, oauth2_cc, or oauth2_ac (optional)")
+	flags.StringVar(&authLevelFlag, "auth_level", "collection", "Where bearer/apikey auth is configured: collection (inherited by requests) or request")
+	flags.StringVar(&apiKeyNameFlag, "api_key_name", "X-Api-Key", "Header or query parameter name carrying the API key (with auth=apikey)")
+	flags.StringVar(&apiKeyPlacementFlag, "api_key_placement", "header", "Where the API key is sent: header or query (with auth=apikey)")
+	flags.StringVar(&oauth2TokenURLFlag, "oauth2_token_url", "", "OAuth2 token endpoint (with auth=oauth2_cc or oauth2_ac)")
+	flags.Var(wordList{&oauth2ScopesFlag}, "oauth2_scopes", "Space-separated OAuth2 scopes to request (with auth=oauth2_cc or oauth2_ac)")
+	flags.StringVar(&oauth2AuthorizeURLFlag, "oauth2_authorize_url", "", "OAuth2 authorization endpoint (with auth=oauth2_ac)")
+	flags.StringVar(&oauth2CallbackURLFlag, "oauth2_callback_url", "", "OAuth2 redirect URL registered for the client (with auth=oauth2_ac)")
+	flags.StringVar(&oauth2PKCEFlag, "oauth2_pkce", "true", "Use PKCE for the authorization code flow (with auth=oauth2_ac)")
+	flags.StringVar(&loginPathFlag, "login_path", "", "Login endpoint path (e.g., /v1/auth/login); generates Auth/Login.bru that stores the returned token")
+	flags.StringVar(&loginMethodFlag, "login_method", "post", "HTTP method of the login endpoint")
+	flags.StringVar(&loginTokenFieldFlag, "login_token_field", "token", "Dot-separated path of the token in the login response body (e.g., data.access_token)")
+	flags.StringVar(&openAPISecurityFlag, "openapi_security", "false", "Derive per-request auth from grpc-gateway openapiv2 security definitions")
+	flags.StringVar(&mtlsFlag, "mtls", "false", "Configure client certificates for mutual TLS")
+	flags.StringVar(&mtlsCertFlag, "mtls_cert", "certs/client.crt", "Client certificate path relative to the collection (with mtls=true)")
+	flags.StringVar(&mtlsKeyFlag, "mtls_key", "certs/client.key", "Client private key path relative to the collection (with mtls=true)")
+	flags.StringVar(&hmacSignFlag, "hmac_sign", "false", "Sign every HTTP request with an HMAC pre-request script (or use the (bruno.v1.hmac_sign) method option)")
+	flags.StringVar(&hmacHeaderFlag, "hmac_header", "X-Signature", "Header carrying the HMAC signature")
+	flags.StringVar(&idempotencyKeyFlag, "idempotency_key", "false", "Send a fresh UUID idempotency key header on POST/PUT requests")
+	flags.StringVar(&idempotencyHeaderFlag, "idempotency_header", "Idempotency-Key", "Header carrying the idempotency key")
+	flags.StringVar(&csrfEndpointFlag, "csrf_endpoint", "", "Path fetched before state-changing requests to obtain a CSRF token (e.g., /v1/csrf)")
+	flags.StringVar(&csrfCookieFlag, "csrf_cookie", "", "Cookie set by csrf_endpoint that carries the CSRF token (e.g., XSRF-TOKEN)")
+	flags.StringVar(&csrfHeaderFlag, "csrf_header", "X-CSRF-Token", "Header used to echo the CSRF token")
+	flags.StringVar(&secretsFlag, "secrets", "", "Keep credentials out of generated files: secret-vars or dotenv (optional)")
+	flags.Var(wordList{&envAuthFlag}, "env_auth", "Space-separated Environment=mode pairs selecting auth per environment: none, bearer, apikey, basic or oauth2_cc")
+	flags.StringVar(&devJWTFlag, "dev_jwt", "false", "Sign a development JWT as the bearer token in the Local environment")
+	flags.Var(wordList{&devJWTClaimsFlag}, "dev_jwt_claims", "Space-separated claim=value pairs of the development JWT (default: sub=dev-user)")
+	flags.StringVar(&idTokenCommandFlag, "id_token_command", "", "Command printing an identity token to use as the bearer token, e.g. gcloud auth print-identity-token (optional)")
+	flags.StringVar(&idTokenURLFlag, "id_token_url", "", "Metadata endpoint returning an identity token to use as the bearer token (optional)")
+	flags.StringVar(&refreshPathFlag, "refresh_path", "", "Token refresh endpoint path; expired bearer tokens are refreshed before each request (optional)")
+	flags.StringVar(&refreshTokenFieldFlag, "refresh_token_field", "", "Refresh token field of the refresh request and login/refresh responses (default: refresh_token)")
+	flags.Var(&customHeaders, "header", `Header added to every HTTP request, as "Name: value"; repeatable`)
+	flags.Var(&envHeaders, "env_header", `Header only sent in some environments, as "Env1 Env2:Name: value"; repeatable`)
+	flags.Var(&grpcMetadata, "grpc_metadata", `gRPC metadata sent with every request, through Grpc-Metadata- headers over HTTP, as "key" or "key: value"; repeatable`)
+	flags.StringVar(&timeoutFlag, "timeout", "", "Request timeout in milliseconds written to HTTP request settings (optional)")
+	flags.StringVar(&followRedirectsFlag, "follow_redirects", "", "Whether HTTP requests follow redirects: true or false (optional)")
+	flags.StringVar(&maxRetriesFlag, "max_retries", "", "Re-send rate-limited HTTP requests up to this many times when run in the collection runner (optional)")
+	flags.StringVar(&maxRedirectsFlag, "max_redirects", "", "Maximum number of redirects followed by HTTP requests (optional)")
+	flags.StringVar(&nameTemplate, "request_name_template", "", "Template of request file and display names, with {api}, {service}, {method}, {method_kebab}, {http_method} and {version} placeholders (optional)")
+	flags.StringVar(&userAgentFlag, "user_agent", "", "User-Agent header of HTTP requests (default: protoc-gen-bruno/<version>, none to disable)")
+	flags.StringVar(&traceContextFlag, "trace_context", "false", "Send a fresh W3C traceparent and correlation ID with every request")
+	flags.StringVar(&correlationHeaderFlag, "correlation_header", "", "Correlation ID header sent with trace_context (default: X-Correlation-Id, none to disable)")
+	flags.StringVar(&scriptLibraryFlag, "script_library", "false", "Generate shared lib/*.js helpers that request scripts require instead of inlining them")
+	flags.StringVar(&requestOrderFlag, "request_order", "workflow", "Order of requests within a folder: workflow, declaration or name")
+	flags.StringVar(&fileCaseFlag, "file_case", "pascal", "Case of generated file and folder names: pascal, kebab or snake")
+	flags.StringVar(&layoutFlag, "layout", "service", "Folder layout: service (one folder per service), package (service folders nested by package), version (service folders grouped by API version), resource (requests grouped by the google.api.resource they operate on) or tag (requests grouped by OpenAPI operation tag)")
+	flags.Var(wordList{&folderOrderFlag}, "folder_order", "Space-separated folders listed first in the collection, e.g. \"Auth UserService\" (optional)")
+	flags.StringVar(&assertionsFlag, "assertions", "false", "Generate status and latency assertions on HTTP requests for smoke testing")
+	flags.StringVar(&schemaTestsFlag, "schema_tests", "false", "Generate tests validating HTTP responses against the output message schema")
+	flags.StringVar(&validationTestsFlag, "validation_tests", "false", "Generate tests checking HTTP responses against the buf.validate rules of the output fields")
+	flags.StringVar(&conditionalRequestsFlag, "conditional_requests", "false", "Send If-None-Match and If-Modified-Since headers on Get and List requests")
+	flags.StringVar(&assertStatusFlag, "assert_status", "", "Expected HTTP status of the generated assertions (default: 200)")
+	flags.StringVar(&maxResponseTimeFlag, "max_response_time", "", "Maximum response time in milliseconds asserted on HTTP requests (optional)")
+	flags.StringVar(&authTokenVar, "auth_token_var", "bearer_token", "Collection variable name to use for bearer token, with var_prefix applied (default: bearer_token)")
+	flags.StringVar(&splitBasePathFlag, "split_base_path", "false", "Move the path of environment URLs into a separate base_path variable")
+	flags.StringVar(&globalEnvironmentsFlag, "global_environments", "false", "With separate collections, emit shared global environments instead of per-collection copies")
+	flags.StringVar(&varPrefixFlag, "var_prefix", "", "Prefix applied to all generated variable names (e.g., billing_)")
+	flags.StringVar(&brunoVersionFlag, "bruno_version", "1", "Bruno collection schema version: 1 (legacy) or 2 (Bruno 2.x gRPC layout)")
+
+	g.generate = func(gen *protogen.Plugin) error {
+		var protoFiles []*protogen.File
+		gen.SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL | pluginpb.CodeGeneratorResponse_FEATURE_SUPPORTS_EDITIONS)
+		gen.SupportedEditionsMinimum = descriptorpb.Edition_EDITION_PROTO2
+		gen.SupportedEditionsMaximum = descriptorpb.Edition_EDITION_2023
+
+		s := newState()
+		g.state = s
+		s.collectionMap = collectionMap
+		s.includeServices, s.excludeServices = includeServices, excludeServices
+		s.includeMethods, s.excludeMethods = includeMethods, excludeMethods
+		s.pathRewrites = pathRewrites
+		s.templateDir, s.outDir = templateDir, outDir
+		s.preRequestInline = preRequestInline
+		s.customHeaders, s.envHeaders, s.grpcMetadata = customHeaders, envHeaders, grpcMetadata
+		s.nameTemplate = nameTemplate
+		if debugFlag == "true" {
+			s.debugLog = g.log
+		}
+		s.traceOptions(flags)
+
+		// Parse and validate mode flag
+		mode := modeAll
+		switch modeFlag {
+		case "http", "grpc":
+			mode = generationMode(modeFlag)
+		}
+
+		// Parse and validate Bruno schema version
+		switch brunoVersionFlag {
+		case brunoVersion1, brunoVersion2:
+			s.brunoVersion = brunoVersionFlag
+		default:
+			s.brunoVersion = brunoVersion1
+		}
+
+		// Parse and validate per-request auth
+		switch requestAuthFlag {
+		case "bearer", "apikey", "oauth2_cc", "oauth2_ac":
+			s.requestAuthMode = requestAuthFlag
+		default:
+			s.requestAuthMode = ""
+		}
+
+		// Per-environment auth replaces the single scheme; the auth option only
+		// provides the mode of environments that are not listed
+		if envAuthFlag != "" {
+			s.envAuthModes = parseEnvironmentAuth(envAuthFlag)
+			if scriptAuthModes[s.requestAuthMode] {
+				s.envAuthDefault = s.requestAuthMode
+			}
+			s.requestAuthMode = ""
+		}
+
+		// OAuth2 tokens are fetched by Bruno at the collection level and inherited by requests
+		if (s.requestAuthMode == "oauth2_cc" || s.requestAuthMode == "oauth2_ac") && authMode == "" {
+			authMode = "oauth2"
+		}
+
+		// Bearer and API key auth are written once to collection.bru unless requested per request
+		if (s.requestAuthMode == "bearer" || s.requestAuthMode == "apikey") && authMode == "" && authLevelFlag != "request" {
+			authMode = s.requestAuthMode
+			s.inheritRequestAuth = true
+		}
+		s.oauth2TokenURL = oauth2TokenURLFlag
+		s.oauth2Scopes = oauth2ScopesFlag
+		s.oauth2AuthorizeURL = oauth2AuthorizeURLFlag
+		s.oauth2CallbackURL = oauth2CallbackURLFlag
+		s.oauth2PKCE = oauth2PKCEFlag != "false"
+		s.loginPath = loginPathFlag
+		s.openAPISecurity = openAPISecurityFlag == "true"
+		s.mtlsEnabled = mtlsFlag == "true"
+		s.hmacSignAll = hmacSignFlag == "true"
+		s.idempotencyKey = idempotencyKeyFlag == "true"
+		s.csrfEndpoint = csrfEndpointFlag
+		s.csrfCookie = csrfCookieFlag
+		if csrfHeaderFlag != "" {
+			s.csrfHeader = csrfHeaderFlag
+		}
+		if idempotencyHeaderFlag != "" {
+			s.idempotencyHeader = idempotencyHeaderFlag
+		}
+		switch secretsFlag {
+		case secretsSecretVars, secretsDotenv:
+			s.secretsMode = secretsFlag
+		default:
+			s.secretsMode = ""
+		}
+		s.devJWT = devJWTFlag == "true"
+		if devJWTClaimsFlag != "" {
+			s.devJWTClaims = devJWTClaimsFlag
+		}
+		s.idTokenCommand = idTokenCommandFlag
+		s.idTokenURL = idTokenURLFlag
+		s.refreshPath = refreshPathFlag
+		s.noCollectionConfig = noCollectionConfigFlag == "true"
+		// Requests dropped into another collection cannot require its lib/
+		s.scriptLibrary = scriptLibraryFlag == "true" && !s.noCollectionConfig
+		s.traceContext = traceContextFlag == "true"
+		s.generatorVersion = opts.Version
+		switch userAgentFlag {
+		case "":
+			s.userAgent = "protoc-gen-bruno/" + opts.Version
+		case "none":
+			s.userAgent = ""
+		default:
+			s.userAgent = userAgentFlag
+		}
+		switch correlationHeaderFlag {
+		case "":
+		case "none":
+			s.correlationHeader = ""
+		default:
+			s.correlationHeader = correlationHeaderFlag
+		}
+		if followRedirectsFlag == "true" || followRedirectsFlag == "false" {
+			s.followRedirects = followRedirectsFlag
+		}
+		switch layoutFlag {
+		case layoutPackage, layoutVersion, layoutResource, layoutTag:
+			s.layout = layoutFlag
+		}
+		switch fileCaseFlag {
+		case fileCaseKebab, fileCaseSnake:
+			s.fileCaseStyle = fileCaseFlag
+		default:
+			s.fileCaseStyle = fileCasePascal
+		}
+		switch requestOrderFlag {
+		case requestOrderDeclaration, requestOrderName:
+			s.requestOrder = requestOrderFlag
+		default:
+			s.requestOrder = requestOrderWorkflow
+		}
+		s.assertions = assertionsFlag == "true"
+		s.schemaTests = schemaTestsFlag == "true"
+		s.validationTests = validationTestsFlag == "true"
+		s.folderOrder = strings.Fields(folderOrderFlag)
+		s.conditionalReads = conditionalRequestsFlag == "true"
+		if assertStatusFlag != "" {
+			s.assertStatus = assertStatusFlag
+		}
+		if refreshTokenFieldFlag != "" {
+			s.refreshTokenField = refreshTokenFieldFlag
+		}
+		if hmacHeaderFlag != "" {
+			s.hmacHeader = hmacHeaderFlag
+		}
+		if mtlsCertFlag != "" {
+			s.mtlsCertPath = mtlsCertFlag
+		}
+		if mtlsKeyFlag != "" {
+			s.mtlsKeyPath = mtlsKeyFlag
+		}
+		if loginMethodFlag != "" {
+			s.loginMethod = strings.ToLower(loginMethodFlag)
+		}
+		if loginTokenFieldFlag != "" {
+			s.loginTokenField = loginTokenFieldFlag
+		}
+
+		// Store auth mode globally for request generation
+		s.collectionAuthMode = authMode
+		if apiKeyNameFlag != "" {
+			s.apiKeyName = apiKeyNameFlag
+		}
+		switch apiKeyPlacementFlag {
+		case "query":
+			s.apiKeyPlacement = "queryparams"
+		default:
+			s.apiKeyPlacement = "header"
+		}
+
+		switch collectionPerFlag {
+		case collectionPerAll, collectionPerPackage, collectionPerService, collectionPerFile:
+			s.collectionPer = collectionPerFlag
+		default:
+			// single_collection=false predates collection_per and splits by package
+			s.collectionPer = collectionPerAll
+			if singleCollectionFlag == "false" || len(s.collectionMap) > 0 {
+				s.collectionPer = collectionPerPackage
+			}
+		}
+		s.collectionReadme = collectionReadmeFlag == "true"
+		s.includeImports = includeImportsFlag == "true"
+		switch writeModeFlag {
+		case writeModeSkipExisting, writeModeMerge:
+			if s.outDir == "" {
+				return fmt.Errorf("write_mode=%s needs out_dir to find the existing files", writeModeFlag)
+			}
+			s.writeMode = writeModeFlag
+		default:
+			s.writeMode = writeModeOverwrite
+		}
+		switch formatFlag {
+		case formatBruno, formatHTTP, formatHoppscotch, formatK6, formatHurl, formatScripts:
+			s.outputFormat = formatFlag
+		default:
+			return fmt.Errorf("unknown format %q, expected bruno, http, hoppscotch, k6, hurl or scripts", formatFlag)
+		}
+		s.openAPISpec = openAPIFlag == "true"
+		switch invalidHTTPRulesFlag {
+		case invalidRulesFail, invalidRulesPlaceholder:
+			s.invalidHTTPRules = invalidHTTPRulesFlag
+		default:
+			s.invalidHTTPRules = invalidRulesSkip
+		}
+		s.manifest = manifestFlag == "true"
+		s.stats = statsFlag == "true"
+		s.verify = verifyFlag == "true"
+		s.insertionPoints = insertionPointsFlag == "true"
+		if s.verify && s.outDir == "" {
+			return fmt.Errorf("verify needs out_dir to find the existing files")
+		}
+		s.dryRun = dryRunFlag == "true"
+		if s.dryRun && s.verify {
+			return fmt.Errorf("dry_run and verify cannot be combined")
+		}
+		s.reportChangedFiles = reportChangesFlag == "true"
+		s.hashFiles = hashesFlag == "true" || s.reportChangedFiles
+		if s.reportChangedFiles && s.outDir == "" {
+			return fmt.Errorf("report_changes needs out_dir to find the previous hashes.json")
+		}
+		if n, err := strconv.Atoi(maxCollectionRequestsFlag); err == nil && n > 0 {
+			s.maxCollectionRequests = n
+		}
+		s.exampleLimits = examples.DefaultLimits
+		if n, err := strconv.Atoi(maxExampleDepthFlag); err == nil && n >= 0 {
+			s.exampleLimits.Depth = n
+		}
+		if n, err := strconv.Atoi(maxExampleSizeFlag); err == nil && n >= 0 {
+			s.exampleLimits.Size = n
+		}
+		var err error
+		if s.requestTimeout, err = countFlag("timeout", timeoutFlag, -1); err != nil {
+			return err
+		}
+		if s.maxRedirects, err = countFlag("max_redirects", maxRedirectsFlag, -1); err != nil {
+			return err
+		}
+		if s.maxRetries, err = countFlag("max_retries", maxRetriesFlag, 0); err != nil {
+			return err
+		}
+		if s.maxResponseTime, err = countFlag("max_response_time", maxResponseTimeFlag, -1); err != nil {
+			return err
+		}
+		if s.outPrefix, err = outputPrefix(outPrefixFlag); err != nil {
+			return err
+		}
+		if err := s.loadTemplates(s.templateDir); err != nil {
+			return err
+		}
+		// Only collections of both protocols have gRPC sibling folders to merge
+		s.unifiedFolders = unifiedFoldersFlag == "true" && mode == modeAll
+		s.splitBasePath = splitBasePathFlag == "true"
+		s.varPrefix = varPrefixFlag
+		// The token variable is generated like the others, so it is prefixed too
+		s.collectionTokenVar = s.varName(authTokenVar)
+		// Global environments only apply when output is split into several collections
+		s.globalEnvironments = globalEnvironmentsFlag == "true" && (s.collectionPer != collectionPerAll || s.maxCollectionRequests > 0)
+
+		// Build environment configurations
+		environments := envgen.Build(envgen.Config{
+			LocalURL:     localURL,
+			DevURL:       devURL,
+			StgURL:       stgURL,
+			PrdURL:       prdURL,
+			GRPCLocalURL: grpcLocalURL,
+			GRPCDevURL:   grpcDevURL,
+			GRPCStgURL:   grpcStgURL,
+			GRPCPrdURL:   grpcPrdURL,
+		})
+
+		// Docs show commands against the first environment
+		if len(environments) > 0 {
+			s.curlBaseURL = environments[0].HTTPURL
+		}
+
+		// Separate the API prefix from the host so it can vary per environment
+		if s.splitBasePath {
+			for i := range environments {
+				environments[i].HTTPURL, environments[i].BasePath = envgen.SplitURLPath(environments[i].HTTPURL)
+			}
+		}
+
+		// Collect all proto files first, with the imported files defining
+		// services when a thin API surface proto imports them
+		imports := directImports(gen.Files)
+		for _, f := range gen.Files {
+			switch {
+			case f.Generate:
+				s.tracef("file %s: generated", f.Desc.Path())
+			case s.includeImports && len(f.Services) > 0 && imports[f.Desc.Path()]:
+				s.tracef("file %s: import with services, generated by include_imports", f.Desc.Path())
+			default:
+				s.tracef("file %s: import, not generated", f.Desc.Path())
+				continue
+			}
+			protoFiles = append(protoFiles, f)
+		}
+		protoFiles = s.filterServices(protoFiles)
+		s.findQualifiedCollections(protoFiles)
+		s.findCollectionSplits(protoFiles, mode)
+
+		// Services with the same name in different packages would overwrite each other's folders
+		s.findCollidingServices(protoFiles)
+		s.findGroupFolders(gen.Files, protoFiles)
+
+		// Security definitions may live in any file, including imports
+		if s.openAPISecurity {
+			s.loadOpenAPISecurity(gen.Files)
+		}
+
+		// Record per-method auth overrides and signing so environments declare their credentials
+		for _, f := range protoFiles {
+			for _, service := range f.Services {
+				for _, method := range service.Methods {
+					if override := methodAuthOverride(method); override != "" {
+						s.methodAuthModes[override] = true
+					}
+					if s.methodHMACSign(method) {
+						s.hmacSigningUsed = true
+					}
+				}
+			}
+		}
+
+		if s.openAPISpec && mode.http() {
+			if err := s.generateOpenAPISpecs(gen, protoFiles, environments, collectionNameFlag); err != nil {
+				return err
+			}
+		}
+
+		if s.outputFormat != formatBruno {
+			return s.generateFormat(gen, protoFiles, environments, collectionNameFlag, mode)
+		}
+
+		// Generate config - either once for single collection or per module
+		configGenerated := make(map[string]bool)
+
+		for _, f := range protoFiles {
+			for _, collectionPrefix := range s.fileCollectionPrefixes(f) {
+				// Generate config once per collection
+				if !configGenerated[collectionPrefix] {
+					if s.noCollectionConfig {
+						// Requests still rely on the login request for their token
+						s.generateLoginFolder(gen, collectionPrefix, mode)
+					} else {
+						s.generateCollectionConfigWithPrefix(gen, s.collectionFiles(protoFiles, collectionPrefix), collectionPrefix, collectionNameFlag, environments, protoRootFlag, preRequestScriptPath, postRequestScriptPath, authMode, s.collectionTokenVar, mode)
+					}
+					configGenerated[collectionPrefix] = true
+				}
+
+				s.tracef("file %s: collection %q", f.Desc.Path(), collectionPrefix)
+				if err := s.generateBrunoCollectionWithPrefix(gen, f, collectionPrefix, mode); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := s.writeRequests(); err != nil {
+			return err
+		}
+
+		if s.stats {
+			if err := s.generateStats(gen, protoFiles); err != nil {
+				return err
+			}
+		}
+
+		if s.manifest {
+			if err := s.generateManifests(gen); err != nil {
+				return err
+			}
+		}
+
+		if s.globalEnvironments && !s.noCollectionConfig && len(configGenerated) > 0 {
+			return s.generateGlobalEnvironments(gen, environments, mode)
+		}
+		return nil
+	}
+
+	for _, param := range append(opts.params(), opts.Params...) {
+		name, value, _ := strings.Cut(param, "=")
+		if err := g.Set(name, value); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// params returns the typed options that are set as plugin options, applied
+// before Params
+func (opts Options) params() []string {
+	var params []string
+	for _, option := range []struct{ name, value string }{
+		{"mode", opts.Mode},
+		{"format", opts.Format},
+		{"bruno_version", opts.BrunoVersion},
+		{"collection_name", opts.CollectionName},
+		{"collection_per", opts.CollectionPer},
+		{"layout", opts.Layout},
+		{"out_dir", opts.OutDir},
+		{"out_prefix", opts.OutPrefix},
+		{"write_mode", opts.WriteMode},
+	} {
+		if option.value != "" {
+			params = append(params, option.name+"="+option.value)
+		}
+	}
+	for _, option := range []struct {
+		name   string
+		values []string
+	}{
+		{"include_services", opts.IncludeServices},
+		{"exclude_services", opts.ExcludeServices},
+		{"include_methods", opts.IncludeMethods},
+		{"exclude_methods", opts.ExcludeMethods},
+		{"header", opts.Headers},
+	} {
+		for _, value := range option.values {
+			params = append(params, option.name+"="+value)
+		}
+	}
+	return params
+}
+
+// directImports returns the paths of the files the files to generate import
+// directly, leaving out Google's files, like google/longrunning/operations.proto
+// and the well-known types, whose services are not part of the API
+func directImports(files []*protogen.File) map[string]bool {
+	imports := make(map[string]bool)
+	for _, f := range files {
+		if !f.Generate {
+			continue
+		}
+		for i := 0; i < f.Desc.Imports().Len(); i++ {
+			if path := f.Desc.Imports().Get(i).Path(); !strings.HasPrefix(path, "google/") {
+				imports[path] = true
+			}
+		}
+	}
+	return imports
+}
+
+// countFlag parses the value of a numeric option, which must be a
+// non-negative integer, returning unset when the option is not given
+func countFlag(name, value string, unset int) (int, error) {
+	if value == "" {
+		return unset, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s=%s, expected a non-negative integer", name, value)
+	}
+	return n, nil
+}
+
+// varName returns the name of a generated variable with the configured prefix applied
+func (s *state) varName(name string) string {
+	return s.varPrefix + name
+}
+
+// bearerTokenVar returns the variable holding the bearer token: the auth_token_var
+// of auth_mode=bearer, or the token variable of the auth option
+func (s *state) bearerTokenVar() string {
+	if s.collectionAuthMode == "bearer" && !s.inheritRequestAuth {
+		return s.collectionTokenVar
+	}
+	return s.varName("token")
+}
+
+// varRef returns a Bruno interpolation reference to a generated variable
+func (s *state) varRef(name string) string {
+	return "{{" + s.varName(name) + "}}"
+}
+
+// baseURLRef returns the variable references that prefix every HTTP request URL
+func (s *state) baseURLRef() string {
+	if s.splitBasePath {
+		return s.varRef("base_url") + s.varRef("base_path")
+	}
+	return s.varRef("base_url")
+}
+
+// collectionPrefix returns the path prefix of the collection a service belongs
+// to, including the sub-collections of oversized collections
+func (s *state) collectionPrefix(f *protogen.File, service *protogen.Service) string {
+	if split, ok := s.collectionSplits[service.Desc.FullName()]; ok {
+		return split.prefix
+	}
+	return s.configuredCollectionPrefix(f, service)
+}
+
+// configuredCollectionPrefix returns the path prefix of the collection a
+// service is configured to belong to: none for a single collection, else a
+// subfolder named after its package, the service itself or its file
+func (s *state) configuredCollectionPrefix(f *protogen.File, service *protogen.Service) string {
+	// Mapped packages are grouped regardless of how the rest is split
+	if mapping, ok := s.mappedCollection(string(f.Desc.Package())); ok {
+		return mapping.folder() + "/"
+	}
+	switch s.collectionPer {
+	case collectionPerFile:
+		// The whole path keeps files sharing a base name apart, e.g. v1_user and v2_user
+		return naming.SanitizeFile(strings.ReplaceAll(strings.TrimSuffix(f.Desc.Path(), ".proto"), "/", "_")) + "/"
+	case collectionPerPackage:
+		if pkg := string(f.Desc.Package()); pkg != "" {
+			return strings.ReplaceAll(pkg, ".", "_") + "/"
+		}
+	case collectionPerService:
+		name := naming.SnakeCase(service.GoName)
+		if s.qualifiedCollections[service.Desc.FullName()] {
+			name = strings.ReplaceAll(string(f.Desc.Package()), ".", "_") + "_" + name
+		}
+		return naming.SanitizeFile(name) + "/"
+	}
+	return ""
+}
+
+// protoFileBase returns the name of a proto file without its directory and
+// extension, e.g. user_service for example/v1/user_service.proto
+func protoFileBase(f *protogen.File) string {
+	return strings.TrimSuffix(path.Base(f.Desc.Path()), ".proto")
+}
+
+// fileCollectionPrefixes returns the prefixes of the collections holding the
+// services of a file, in declaration order
+func (s *state) fileCollectionPrefixes(f *protogen.File) []string {
+	var prefixes []string
+	seen := make(map[string]bool)
+	for _, service := range f.Services {
+		prefix := s.collectionPrefix(f, service)
+		if !seen[prefix] {
+			seen[prefix] = true
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
+// collectionFiles returns the files with services in the collection of a prefix
+func (s *state) collectionFiles(protoFiles []*protogen.File, prefix string) []*protogen.File {
+	var files []*protogen.File
+	for _, f := range protoFiles {
+		for _, service := range f.Services {
+			if s.collectionPrefix(f, service) == prefix {
+				files = append(files, f)
+				break
+			}
+		}
+	}
+	return files
+}
+
+func (s *state) generateCollectionConfigWithPrefix(gen *protogen.Plugin, protoFiles []*protogen.File, prefix string, customName string, environments []envgen.Environment, protoRoot string, preRequestScriptPath string, postRequestScriptPath string, authMode string, authTokenVar string, mode generationMode) {
+	s.generateCollectionConfig(gen, protoFiles, prefix, customName, environments, protoRoot, preRequestScriptPath, postRequestScriptPath, authMode, authTokenVar, mode)
+}
+
+// expandCollectionName fills the {package}, {version} and {service}
+// placeholders of a collection_name template with the packages, API versions
+// and services of a collection, joined with " & " when there are several
+func (s *state) expandCollectionName(template string, protoFiles []*protogen.File, prefix string) string {
+	if !strings.Contains(template, "{") {
+		return template
+	}
+	var packages, versions, services []string
+	seen := make(map[string]bool)
+	add := func(list *[]string, kind string, value string) {
+		if value != "" && !seen[kind+value] {
+			seen[kind+value] = true
+			*list = append(*list, value)
+		}
+	}
+	for _, f := range protoFiles {
+		for _, service := range f.Services {
+			if s.collectionPrefix(f, service) != prefix {
+				continue
+			}
+			pkg := string(f.Desc.Package())
+			_, version := packageAPIVersion(pkg)
+			add(&packages, "package:", pkg)
+			add(&versions, "version:", version)
+			add(&services, "service:", service.GoName)
+		}
+	}
+	return strings.NewReplacer(
+		"{package}", strings.Join(packages, " & "),
+		"{version}", strings.Join(versions, " & "),
+		"{service}", strings.Join(services, " & "),
+	).Replace(template)
+}
+
+// collectionDisplayName returns the name of the collection with a prefix: a
+// mapped or split collection name, the collection_name option, or a name
+// derived from its file, services or package
+func (s *state) collectionDisplayName(protoFiles []*protogen.File, prefix string, customName string) string {
+	// Use custom name if provided, otherwise auto-generate
+	collectionName := "API Collection"
+
+	if name, ok := s.mappedCollectionName(prefix); ok {
+		collectionName = name
+	} else if name, ok := s.splitCollectionName(prefix); ok {
+		collectionName = name
+	} else if customName != "" {
+		collectionName = s.expandCollectionName(customName, protoFiles, prefix)
+	} else if s.collectionPer == collectionPerFile && len(protoFiles) > 0 {
+		// Name the collection after its file, e.g. user_service.proto -> "User Service API"
+		collectionName = naming.PackageTitle(strings.NewReplacer("_", ".", "-", ".").Replace(protoFileBase(protoFiles[0]))) + " API"
+	} else {
+		// Build collection name from services
+		var serviceNames []string
+
+		for _, f := range protoFiles {
+			for _, service := range f.Services {
+				if s.collectionPrefix(f, service) != prefix {
+					continue
+				}
+				if s.qualifiedCollections[service.Desc.FullName()] {
+					serviceNames = append(serviceNames, service.GoName+" ("+string(f.Desc.Package())+")")
+				} else {
+					serviceNames = append(serviceNames, service.GoName)
+				}
+			}
+		}
+
+		if len(serviceNames) > 0 {
+			if len(serviceNames) == 1 {
+				collectionName = serviceNames[0] + " API"
+			} else {
+				// Use the package name or first service for multiple services
+				if len(protoFiles) > 0 {
+					pkg := string(protoFiles[0].Desc.Package())
+					if pkg != "" {
+						// Convert package name like "example.v1" to "Example V1 API"
+						collectionName = naming.PackageTitle(pkg) + " API"
+					} else {
+						collectionName = strings.Join(serviceNames, " & ") + " APIs"
+					}
+				}
+			}
+		}
+	}
+
+	return naming.Sanitize(collectionName)
+}
+
+func (s *state) generateCollectionConfig(gen *protogen.Plugin, protoFiles []*protogen.File, prefix string, customName string, environments []envgen.Environment, protoRoot string, preRequestScriptPath string, postRequestScriptPath string, authMode string, authTokenVar string, mode generationMode) {
+	collectionName := s.collectionDisplayName(protoFiles, prefix, customName)
+
+	// Read pre-request script if provided
+	var preRequestScript string
+	if preRequestScriptPath != "" {
+		scriptBytes, err := os.ReadFile(preRequestScriptPath)
+		if err != nil {
+			// If file can't be read, log warning but continue
+			gen.Error(fmt.Errorf("warning: could not read pre-request script file %s: %v", preRequestScriptPath, err))
+		} else {
+			preRequestScript = string(scriptBytes)
+		}
+	}
+
+	// Read post-request script if provided
+	var postRequestScript string
+	if postRequestScriptPath != "" {
+		scriptBytes, err := os.ReadFile(postRequestScriptPath)
+		if err != nil {
+			// If file can't be read, log warning but continue
+			gen.Error(fmt.Errorf("warning: could not read post-request script file %s: %v", postRequestScriptPath, err))
+		} else {
+			postRequestScript = string(scriptBytes)
+		}
+	}
+
+	// Generated snippets run before any user-provided script; tokens are
+	// obtained first so per-environment auth can send them
+	var snippets []string
+	if s.devJWT {
+		snippets = append(snippets, strings.Join(s.devJWTScript(), "\n"))
+	}
+	if s.idTokenCommand != "" || s.idTokenURL != "" {
+		snippets = append(snippets, strings.Join(s.idTokenScript(), "\n"))
+	}
+	if s.refreshPath != "" {
+		snippets = append(snippets, strings.Join(s.tokenRefreshScript(), "\n"))
+	}
+	if s.traceContext {
+		snippets = append(snippets, strings.Join(s.traceContextScript(), "\n"))
+	}
+	if len(s.envHeaders) > 0 {
+		snippets = append(snippets, strings.Join(s.envHeadersScript(), "\n"))
+	}
+	if s.envAuthModes != nil {
+		snippets = append(snippets, strings.Join(s.environmentAuthScript(environments), "\n"))
+	}
+	if preRequestScript != "" {
+		snippets = append(snippets, strings.TrimRight(preRequestScript, "\n"))
+	}
+	if s.preRequestInline != "" {
+		snippets = append(snippets, s.preRequestInline)
+	}
+	preRequestScript = strings.Join(snippets, "\n\n")
+
+	// Generate bruno.json with proto paths
+	brunoConfig := gen.NewGeneratedFile(prefix+"bruno.json", "")
+	brunoConfig.P("{")
+	brunoConfig.P(`  "version": "1",`)
+	quotedName, _ := json.Marshal(collectionName)
+	brunoConfig.P(`  "name": `, string(quotedName), `,`)
+
+	// Collect optional top-level sections; each is written without a trailing comma
+	var sections [][]string
+
+	// Add protobuf config if needed (for gRPC support)
+	if mode.grpc() {
+		if s.brunoVersion == brunoVersion2 {
+			sections = append(sections, jsonSection("protobuf", protobufConfigV2(protoFiles, protoRoot)))
+		} else {
+			sections = append(sections, jsonSection("protobuf", protobufSectionV1{Proto: protoRootConfig{Root: protoRoot}}))
+		}
+	}
+
+	// Add client certificates for gateways that require mutual TLS
+	if s.mtlsEnabled {
+		sections = append(sections, jsonSection("clientCertificates", s.clientCertificatesConfig(environments)))
+	}
+
+	// Add comma after "type" if more sections follow
+	if len(sections) > 0 {
+		brunoConfig.P(`  "type": "collection",`)
+	} else {
+		brunoConfig.P(`  "type": "collection"`)
+	}
+
+	for i, section := range sections {
+		for j, line := range section {
+			if j == len(section)-1 && i < len(sections)-1 {
+				line += ","
+			}
+			brunoConfig.P(line)
+		}
+	}
+
+	brunoConfig.P("}")
+
+	// Generate collection.bru file with auth, scripts and docs
+	w := s.newBruWriter(gen.NewGeneratedFile(prefix+"collection.bru", ""))
+
+	// Add auth configuration if specified
+	if authMode != "" {
+		w.open("auth")
+		w.entry("mode", authMode)
+		w.close()
+
+		// Add auth-specific configuration based on mode
+		switch {
+		case s.inheritRequestAuth:
+			s.generateAuthBlock(w, s.requestAuthMode)
+		case authMode == "bearer":
+			w.open("auth:bearer")
+			w.entry("token", s.rawCredentialRef(authTokenVar))
+			w.close()
+		case authMode == "basic":
+			w.open("auth:basic")
+			w.entry("username", s.credentialRef("username"))
+			w.entry("password", s.credentialRef("password"))
+			w.close()
+		case authMode == "apikey":
+			w.open("auth:apikey")
+			w.entry("key", s.varRef("api_key"))
+			w.entry("value", s.credentialRef("api_key_value"))
+			w.entry("placement", "header")
+			w.close()
+		case authMode == "awsv4":
+			w.open("auth:awsv4")
+			w.entry("accessKeyId", s.credentialRef("aws_access_key_id"))
+			w.entry("secretAccessKey", s.credentialRef("aws_secret_access_key"))
+			w.entry("sessionToken", s.credentialRef("aws_session_token"))
+			w.entry("service", s.varRef("aws_service"))
+			w.entry("region", s.varRef("aws_region"))
+			w.close()
+		case authMode == "oauth2":
+			w.open("auth:oauth2")
+			if s.requestAuthMode == "oauth2_ac" {
+				w.entry("grant_type", "authorization_code")
+				w.entry("callback_url", s.varRef("oauth2_callback_url"))
+				w.entry("authorization_url", s.varRef("oauth2_authorize_url"))
+			} else {
+				w.entry("grant_type", "client_credentials")
+			}
+			w.entry("access_token_url", s.varRef("oauth2_token_url"))
+			w.entry("client_id", s.credentialRef("oauth2_client_id"))
+			w.entry("client_secret", s.credentialRef("oauth2_client_secret"))
+			scopes := s.oauth2Scopes
+			if scopes == "" {
+				scopes = strings.Join(serviceOAuthScopes(protoFiles), " ")
+			}
+			w.entry("scope", scopes)
+			if s.requestAuthMode == "oauth2_ac" {
+				w.entry("pkce", s.oauth2PKCE)
+			}
+			w.close()
+		}
+	}
+
+	if preRequestScript != "" || s.insertionPoints {
+		w.open("script:pre-request")
+		if preRequestScript != "" {
+			w.text(preRequestScript)
+		}
+		w.close()
+	}
+
+	if postRequestScript != "" || s.insertionPoints {
+		w.open("script:post-response")
+		if postRequestScript != "" {
+			w.text(postRequestScript)
+		}
+		w.close()
+	}
+
+	w.open("docs")
+	if s.envAuthModes != nil {
+		s.generateEnvironmentAuthDocs(w, environments)
+		w.text("")
+	}
+	w.text(s.generationStamp(protoFiles))
+	w.close()
+
+	// Overview of the collection for people opening it for the first time
+	if s.collectionReadme {
+		s.generateCollectionReadme(gen, protoFiles, prefix, collectionName, environments, mode)
+	}
+
+	// Helpers shared by the request scripts
+	if s.scriptLibrary && mode.http() {
+		s.generateScriptLibrary(gen, prefix)
+	}
+
+	s.generateLoginFolder(gen, prefix, mode)
+
+	// Credentials read from process.env are listed for the local .env file
+	if s.secretsMode == secretsDotenv && len(environments) > 0 {
+		s.generateDotenvExample(gen, prefix, s.environmentVars(environments[0], mode))
+	}
+
+	// Shared global environments replace the per-collection copies
+	if s.globalEnvironments {
+		return
+	}
+
+	// Generate environment files for each configured environment
+	for _, env := range environments {
+		w := s.newBruWriter(gen.NewGeneratedFile(prefix+"environments/"+env.Name+".bru", ""))
+		var secrets []string
+		w.open("vars")
+		for _, v := range s.applySecretsMode(s.environmentVars(env, mode)) {
+			if v.secret {
+				secrets = append(secrets, v.name)
+				continue
+			}
+			w.entry(v.name, v.value)
+		}
+		w.close()
+
+		// Secret values are kept out of the file and filled in locally in Bruno
+		if len(secrets) > 0 {
+			w.listBlock("vars:secret", secrets)
+		}
+	}
+}
+
+type environmentVar struct {
+	name   string
+	value  string
+	secret bool
+	// credential marks values that must never be written to generated files
+	// when a secrets mode is selected
+	credential bool
+}
+
+// environmentVars returns the variables defined for an environment, in output order
+func (s *state) environmentVars(env envgen.Environment, mode generationMode) []environmentVar {
+	var vars []environmentVar
+
+	// Add relevant environment variables based on mode
+	if mode.http() {
+		vars = append(vars, environmentVar{name: s.varName("base_url"), value: env.HTTPURL})
+		if s.splitBasePath {
+			vars = append(vars, environmentVar{name: s.varName("base_path"), value: env.BasePath})
+		}
+	}
+	if mode.grpc() {
+		vars = append(vars, environmentVar{name: s.varName("grpc_url"), value: env.GRPCURL})
+	}
+
+	// Credentials used by bearer/apikey auth; gRPC requests only use them when inherited
+	if mode.http() || s.inheritRequestAuth {
+		switch s.requestAuthMode {
+		case "bearer":
+			vars = append(vars, environmentVar{name: s.varName("token"), secret: true, credential: true})
+		case "apikey":
+			vars = append(vars, environmentVar{name: s.varName("api_key"), secret: true, credential: true})
+		}
+	}
+
+	// Credentials used by methods that override the auth scheme
+	if mode.http() {
+		if s.methodAuthModes["bearer"] {
+			vars = append(vars, environmentVar{name: s.varName("token"), secret: true, credential: true})
+		}
+		if s.methodAuthModes["apikey"] {
+			vars = append(vars, environmentVar{name: s.varName("api_key"), secret: true, credential: true})
+		}
+		if s.methodAuthModes["basic"] {
+			vars = append(vars,
+				environmentVar{name: s.varName("username"), credential: true},
+				environmentVar{name: s.varName("password"), secret: true, credential: true},
+			)
+		}
+	}
+
+	// Login credentials posted by the Auth/Login request
+	if s.loginPath != "" && mode.http() {
+		vars = append(vars,
+			environmentVar{name: s.varName("username"), credential: true},
+			environmentVar{name: s.varName("password"), secret: true, credential: true},
+		)
+	}
+
+	// Refresh token kept up to date by the login request and refresh script
+	if s.refreshPath != "" {
+		vars = append(vars, environmentVar{name: s.varName("refresh_token"), secret: true})
+	}
+
+	// Credentials of the auth mode selected for this environment
+	if s.envAuthModes != nil {
+		vars = append(vars, s.environmentAuthVars(env)...)
+	}
+
+	// Headers enabled per environment
+	vars = append(vars, s.environmentHeaderVars(env)...)
+	vars = append(vars, s.environmentMetadataVars()...)
+
+	// Shared secret used by HMAC request signing
+	if s.hmacSigningUsed && mode.http() {
+		vars = append(vars, environmentVar{name: s.varName("hmac_secret"), secret: true, credential: true})
+	}
+
+	// Shared secret the development JWT is signed with, only used locally
+	if s.devJWT && env.Name == "Local" {
+		vars = append(vars, environmentVar{name: s.varName("jwt_secret"), secret: true, credential: true})
+	}
+
+	// Credentials referenced by documented OpenAPI security schemes
+	if s.openAPISecurity && mode.http() {
+		vars = append(vars, s.openAPISecurityVars()...)
+	}
+
+	// Client credentials used by collection-level OAuth2
+	if s.collectionAuthMode == "oauth2" {
+		if s.requestAuthMode == "oauth2_ac" {
+			vars = append(vars,
+				environmentVar{name: s.varName("oauth2_authorize_url"), value: s.oauth2AuthorizeURL},
+				environmentVar{name: s.varName("oauth2_callback_url"), value: s.oauth2CallbackURL},
+			)
+		}
+		vars = append(vars,
+			environmentVar{name: s.varName("oauth2_token_url"), value: s.oauth2TokenURL},
+			environmentVar{name: s.varName("oauth2_client_id"), credential: true},
+			environmentVar{name: s.varName("oauth2_client_secret"), secret: true, credential: true},
+		)
+	}
+
+	// Credentials of the auth_mode collection auth are only declared when a
+	// secrets mode asks for them to be tracked
+	if s.secretsMode != "" && !s.inheritRequestAuth {
+		switch s.collectionAuthMode {
+		case "bearer":
+			vars = append(vars, environmentVar{name: s.collectionTokenVar, secret: true, credential: true})
+		case "basic":
+			vars = append(vars,
+				environmentVar{name: s.varName("username"), credential: true},
+				environmentVar{name: s.varName("password"), secret: true, credential: true},
+			)
+		case "apikey":
+			vars = append(vars,
+				environmentVar{name: s.varName("api_key")},
+				environmentVar{name: s.varName("api_key_value"), secret: true, credential: true},
+			)
+		case "awsv4":
+			vars = append(vars,
+				environmentVar{name: s.varName("aws_access_key_id"), credential: true},
+				environmentVar{name: s.varName("aws_secret_access_key"), secret: true, credential: true},
+				environmentVar{name: s.varName("aws_session_token"), secret: true, credential: true},
+				environmentVar{name: s.varName("aws_service")},
+				environmentVar{name: s.varName("aws_region")},
+			)
+		}
+	}
+
+	// Several auth sources can share a variable, so keep the first occurrence only
+	seen := make(map[string]bool)
+	unique := vars[:0]
+	for _, v := range vars {
+		if !seen[v.name] {
+			seen[v.name] = true
+			unique = append(unique, v)
+		}
+	}
+
+	return unique
+}
+
+// generateGlobalEnvironments writes one Bruno global environment per configured
+// environment, in the JSON format accepted by Bruno's global environment import
+func (s *state) generateGlobalEnvironments(gen *protogen.Plugin, environments []envgen.Environment, mode generationMode) error {
+	type globalVariable struct {
+		Name    string `json:"name"`
+		Value   string `json:"value"`
+		Type    string `json:"type"`
+		Enabled bool   `json:"enabled"`
+		Secret  bool   `json:"secret"`
+	}
+	for _, env := range environments {
+		variables := []globalVariable{}
+		for _, v := range s.applySecretsMode(s.environmentVars(env, mode)) {
+			variables = append(variables, globalVariable{Name: v.name, Value: v.value, Type: "text", Enabled: true, Secret: v.secret})
+		}
+		globalEnv := struct {
+			Name      string           `json:"name"`
+			Variables []globalVariable `json:"variables"`
+		}{env.Name, variables}
+		if err := writeJSONFile(gen, "global_environments/"+env.Name+".json", globalEnv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// serviceOAuthScopes collects the scopes declared with google.api.oauth_scopes
+// on the given services, in declaration order and without duplicates
+func serviceOAuthScopes(protoFiles []*protogen.File) []string {
+	var scopes []string
+	seen := make(map[string]bool)
+
+	for _, f := range protoFiles {
+		for _, service := range f.Services {
+			opts := service.Desc.Options()
+			if !proto.HasExtension(opts, annotations.E_OauthScopes) {
+				continue
+			}
+			// The annotation holds a comma-separated list of scopes
+			value := proto.GetExtension(opts, annotations.E_OauthScopes).(string)
+			for _, scope := range strings.Split(value, ",") {
+				scope = strings.TrimSpace(scope)
+				if scope != "" && !seen[scope] {
+					seen[scope] = true
+					scopes = append(scopes, scope)
+				}
+			}
+		}
+	}
+
+	return scopes
+}
+
+// generateLoginFolder writes the Auth folder with the login request that
+// bootstraps the token for the other requests, when login_path is set
+func (s *state) generateLoginFolder(gen *protogen.Plugin, prefix string, mode generationMode) {
+	if s.loginPath != "" && mode.http() {
+		s.generateFolderBru(gen, prefix, s.fileCase("Auth"), "Auth", "")
+		s.generateLoginRequest(gen, prefix, s.bearerTokenVar())
+	}
+}
+
+// generateLoginRequest writes Auth/Login.bru, which posts the login credentials
+// and stores the token from the response in the environment variable used by
+// the rest of the collection
+func (s *state) generateLoginRequest(gen *protogen.Plugin, prefix string, tokenVar string) {
+	w := s.newBruWriter(gen.NewGeneratedFile(prefix+s.fileCase("Auth")+"/"+s.fileCase("Login")+".bru", ""))
+
+	tokenExpr := fieldAccessor("res.body", s.loginTokenField)
+
+	w.open("meta")
+	w.entry("name", "Login")
+	w.entry("type", "http")
+	w.entry("seq", 1)
+	w.close()
+	w.open(s.loginMethod)
+	w.entry("url", s.baseURLRef(), s.loginPath)
+	w.entry("body", "json")
+	w.entry("auth", "none")
+	w.close()
+	w.open("body:json")
+	w.text(
+		"{",
+		`  "username": "`+s.credentialRef("username")+`",`,
+		`  "password": "`+s.credentialRef("password")+`"`,
+		"}",
+	)
+	w.close()
+	w.open("script:post-response")
+	w.text(
+		"const token = "+tokenExpr+";",
+		"if (res.status >= 200 && res.status < 300 && token) {",
+		`  bru.setEnvVar("`+tokenVar+`", token);`,
+		"}",
+	)
+	if s.refreshPath != "" {
+		w.text(
+			"const refreshToken = "+fieldAccessor("res.body", s.refreshTokenField)+";",
+			"if (res.status >= 200 && res.status < 300 && refreshToken) {",
+			`  bru.setEnvVar("`+s.varName("refresh_token")+`", refreshToken);`,
+			"}",
+		)
+	}
+	w.close()
+}
+
+// fieldAccessor builds an optional-chained accessor for a dot-separated field
+// path, e.g. res.body?.data?.access_token
+func fieldAccessor(object string, path string) string {
+	for _, part := range strings.Split(path, ".") {
+		object += "?." + part
+	}
+	return object
+}
+
+// jsonSection returns the lines of a top-level bruno.json section, with its
+// value marshaled so paths and names are escaped
+func jsonSection(key string, value any) []string {
+	quotedKey, _ := json.Marshal(key)
+	content, _ := json.MarshalIndent(value, "  ", "  ")
+	return strings.Split("  "+string(quotedKey)+": "+string(content), "\n")
+}
+
+// protobufSectionV1 is the Bruno 1.x protobuf section, which only holds the
+// proto root
+type protobufSectionV1 struct {
+	Proto protoRootConfig `json:"proto"`
+}
+
+// protoRootConfig is the proto root of a Bruno 1.x protobuf section
+type protoRootConfig struct {
+	Root string `json:"root"`
+}
+
+// protobufSectionV2 is the Bruno 2.x protobuf section
+type protobufSectionV2 struct {
+	ProtoFiles  []protoFileConfig  `json:"protoFiles"`
+	ImportPaths []importPathConfig `json:"importPaths"`
+}
+
+// protoFileConfig is a proto file listed in a Bruno 2.x protobuf section
+type protoFileConfig struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+}
+
+// importPathConfig is an import path of a Bruno 2.x protobuf section
+type importPathConfig struct {
+	Path    string `json:"path"`
+	Enabled bool   `json:"enabled"`
+}
+
+// protobufConfigV2 returns the Bruno 2.x protobuf section, which lists the
+// proto files explicitly and registers the proto root as an import path
+func protobufConfigV2(protoFiles []*protogen.File, protoRoot string) protobufSectionV2 {
+	config := protobufSectionV2{
+		ProtoFiles:  []protoFileConfig{},
+		ImportPaths: []importPathConfig{{Path: protoRoot, Enabled: true}},
+	}
+	for _, f := range protoFiles {
+		if len(f.Services) > 0 {
+			config.ProtoFiles = append(config.ProtoFiles, protoFileConfig{Path: protoRoot + "/" + f.Desc.Path(), Type: "file"})
+		}
+	}
+	return config
+}
+
+// clientCertificates is the bruno.json clientCertificates section
+type clientCertificates struct {
+	Enabled bool                `json:"enabled"`
+	Certs   []clientCertificate `json:"certs"`
+}
+
+// clientCertificate is the certificate of a domain in a clientCertificates
+// section
+type clientCertificate struct {
+	Domain       string `json:"domain"`
+	Type         string `json:"type"`
+	CertFilePath string `json:"certFilePath"`
+	KeyFilePath  string `json:"keyFilePath"`
+	Passphrase   string `json:"passphrase"`
+}
+
+// clientCertificatesConfig returns the bruno.json clientCertificates section with
+// one certificate entry per environment host (HTTP and gRPC)
+func (s *state) clientCertificatesConfig(environments []envgen.Environment) clientCertificates {
+	config := clientCertificates{Enabled: true, Certs: []clientCertificate{}}
+	seen := make(map[string]bool)
+	for _, env := range environments {
+		// gRPC endpoints may be served from a different host than HTTP
+		for _, host := range []string{envgen.GRPCHost(env.HTTPURL), env.GRPCURL} {
+			if idx := strings.LastIndex(host, ":"); idx != -1 {
+				host = host[:idx]
+			}
+			if host != "" && !seen[host] {
+				seen[host] = true
+				config.Certs = append(config.Certs, clientCertificate{
+					Domain:       host,
+					Type:         "cert",
+					CertFilePath: s.mtlsCertPath,
+					KeyFilePath:  s.mtlsKeyPath,
+				})
+			}
+		}
+	}
+	return config
+}
+
+// getServiceFolderName returns the folder name for a service, avoiding conflicts with reserved directories
+func getServiceFolderName(serviceName string) string {
+	// Check if service name conflicts with "environments" (case-insensitive)
+	if strings.ToLower(serviceName) == "environments" {
+		return serviceName + "Service"
+	}
+	return serviceName
+}
+
+// fileCase applies the file_case convention to a PascalCase file or folder
+// name: UserService, user-service or user_service
+func (s *state) fileCase(name string) string {
+	switch s.fileCaseStyle {
+	case fileCaseKebab:
+		return naming.KebabCase(name)
+	case fileCaseSnake:
+		return naming.SnakeCase(name)
+	}
+	return name
+}
+
+// grpcFolderName returns the name of the folder holding the gRPC requests of a
+// service folder
+func (s *state) grpcFolderName(folder string) string {
+	switch s.fileCaseStyle {
+	case fileCaseKebab:
+		return folder + "-grpc"
+	case fileCaseSnake:
+		return folder + "_grpc"
+	}
+	return folder + "-gRPC"
+}
+
+// grpcRequestFolder returns the folder holding the gRPC requests of a folder:
+// the folder itself with unified_folders, else its gRPC sibling
+func (s *state) grpcRequestFolder(folder string) string {
+	if s.unifiedFolders {
+		return folder
+	}
+	return s.grpcFolderName(folder)
+}
+
+// findQualifiedCollections records the services sharing their name with a
+// service of another package, whose collection_per=service collections would
+// otherwise be merged
+func (s *state) findQualifiedCollections(protoFiles []*protogen.File) {
+	s.qualifiedCollections = map[protoreflect.FullName]bool{}
+	if s.collectionPer != collectionPerService {
+		return
+	}
+
+	byName := make(map[string][]*protogen.Service)
+	for _, f := range protoFiles {
+		for _, service := range f.Services {
+			name := naming.SnakeCase(service.GoName)
+			byName[name] = append(byName[name], service)
+		}
+	}
+	for _, services := range byName {
+		if len(services) > 1 {
+			for _, clashing := range services {
+				s.qualifiedCollections[clashing.Desc.FullName()] = true
+			}
+		}
+	}
+}
+
+// findCollidingServices records the services whose folder name is shared by a
+// service of another package in the same collection. Nesting folders by
+// package keeps them apart already.
+func (s *state) findCollidingServices(protoFiles []*protogen.File) {
+	s.collidingServices = map[protoreflect.FullName]bool{}
+	if s.layout == layoutPackage {
+		return
+	}
+
+	byFolder := make(map[string][]*protogen.Service)
+	for _, f := range protoFiles {
+		for _, service := range f.Services {
+			key := s.collectionPrefix(f, service) + s.layoutParent(service) + s.fileCase(getServiceFolderName(service.GoName))
+			byFolder[key] = append(byFolder[key], service)
+		}
+	}
+	for _, services := range byFolder {
+		for _, service := range services[1:] {
+			if service.Desc.ParentFile().Package() != services[0].Desc.ParentFile().Package() {
+				for _, colliding := range services {
+					s.collidingServices[colliding.Desc.FullName()] = true
+				}
+				break
+			}
+		}
+	}
+}
+
+// serviceDisplayName returns the folder display name of a service, naming the
+// package of colliding services: "User Service (admin.v1)"
+func (s *state) serviceDisplayName(service *protogen.Service) string {
+	name := naming.Display(service.GoName)
+	if s.collidingServices[service.Desc.FullName()] {
+		name += " (" + string(service.Desc.ParentFile().Package()) + ")"
+	}
+	return name
+}
+
+// layoutParent returns the folder the service folders of a service's package
+// are nested under: the package segments with layout=package (example/v1/) and
+// the API version with layout=version (v1/). Packages without a version stay
+// at the top level.
+func (s *state) layoutParent(service *protogen.Service) string {
+	pkg := string(service.Desc.ParentFile().Package())
+	switch s.layout {
+	case layoutPackage:
+		if pkg != "" {
+			return strings.ReplaceAll(pkg, ".", "/") + "/"
+		}
+	case layoutVersion:
+		if _, version := packageAPIVersion(pkg); version != "" {
+			return version + "/"
+		}
+	}
+	return ""
+}
+
+// serviceFolder returns the folder holding the requests of a service, nested
+// as the layout requires (example/v1/UserService or v1/UserService)
+func (s *state) serviceFolder(service *protogen.Service) string {
+	folder := naming.SanitizeFile(s.fileCase(getServiceFolderName(service.GoName)))
+	if s.collidingServices[service.Desc.FullName()] {
+		// Prefix the package, e.g. admin_v1_UserService or admin-v1-user-service
+		pkg := strings.Split(string(service.Desc.ParentFile().Package()), ".")
+		if s.fileCaseStyle == fileCaseKebab {
+			folder = strings.Join(append(pkg, folder), "-")
+		} else {
+			folder = strings.Join(append(pkg, folder), "_")
+		}
+	}
+	return s.layoutParent(service) + folder
+}
+
+func (s *state) generateBrunoCollectionWithPrefix(gen *protogen.Plugin, file *protogen.File, prefix string, mode generationMode) error {
+	return s.generateBrunoCollection(gen, file, prefix, mode)
+}
+
+func (s *state) generateBrunoCollection(gen *protogen.Plugin, file *protogen.File, prefix string, mode generationMode) error {
+	// We'll iterate through services and their methods
+	for _, service := range file.Services {
+		if s.collectionPrefix(file, service) != prefix {
+			continue
+		}
+
+		// Describe the service folders, in the order services are generated.
+		// Group folders are shared by services and described with their first
+		// request instead.
+		if !s.groupsMethods() {
+			if mode.http() && (s.serviceHasHTTP(service) || s.unifiedFolders) {
+				s.generateFolderBru(gen, prefix, s.serviceFolder(service), s.serviceDisplayName(service), string(service.Comments.Leading))
+			}
+			if mode.grpc() && !s.unifiedFolders {
+				s.generateFolderBru(gen, prefix, s.grpcFolderName(s.serviceFolder(service)), s.serviceDisplayName(service)+" (gRPC)", string(service.Comments.Leading))
+			}
+		}
+
+		// For each service, create a Bruno collection folder
+		// and generate .bru files for each RPC method
+		for _, method := range service.Methods {
+			if s.groupsMethods() {
+				s.generateMethodFolders(gen, service, method, prefix, mode)
+			}
+			// Generate HTTP request (if mode allows and it has HTTP annotations)
+			if mode.http() {
+				if err := s.generateBrunoRequest(gen, service, method, prefix); err != nil {
+					return err
+				}
+			}
+			// Generate gRPC request (if mode allows)
+			if mode.grpc() {
+				if err := s.generateGrpcRequest(gen, service, method, file, prefix); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// generateFolderBru writes the folder.bru of a folder with a readable name, its
+// position among the collection's folders and docs, such as the service comments
+func (s *state) generateFolderBru(gen *protogen.Plugin, prefix string, folder string, name string, docs string) {
+	w := s.newBruWriter(gen.NewGeneratedFile(prefix+folder+"/folder.bru", ""))
+	w.open("meta")
+	w.entry("name", naming.Sanitize(name))
+	w.entry("seq", s.folderSeq(prefix, folder))
+	w.close()
+
+	docs = strings.TrimSpace(docs)
+	if docs != "" {
+		w.open("docs")
+		for _, line := range strings.Split(docs, "\n") {
+			w.text(strings.TrimPrefix(line, " "))
+		}
+		w.close()
+	}
+}
+
+// folderSeq returns the seq of a folder in its collection. Folders listed in
+// folder_order come first, a service name also placing its gRPC folder right
+// after the HTTP one; the others follow in the order they are written.
+func (s *state) folderSeq(prefix string, folder string) int {
+	folder = path.Base(folder)
+	for i, entry := range s.folderOrder {
+		if s.fileCase(entry) == folder {
+			return 2*i + 1
+		}
+		if s.grpcFolderName(s.fileCase(entry)) == folder {
+			return 2*i + 2
+		}
+	}
+	s.folderSeqs[prefix]++
+	return 2*len(s.folderOrder) + s.folderSeqs[prefix]
+}
+
+// serviceHasHTTP reports whether any method of a service is exposed over HTTP
+func (s *state) serviceHasHTTP(service *protogen.Service) bool {
+	for _, method := range service.Methods {
+		if s.hasHTTPRule(method) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasHTTPRule reports whether an HTTP request is generated for a method: it
+// has a valid google.api.http rule, or a placeholder stands in for it
+func (s *state) hasHTTPRule(method *protogen.Method) bool {
+	_, _, _, problem, ok := s.methodHTTPRule(method)
+	return ok && (problem == "" || s.invalidHTTPRules == invalidRulesPlaceholder)
+}
+
+func (s *state) generateBrunoRequest(gen *protogen.Plugin, service *protogen.Service, method *protogen.Method, prefix string) error {
+	// Extract HTTP annotation from method options
+	httpRule, httpMethod, path, problem, ok := s.methodHTTPRule(method)
+	if !ok {
+		// Skip methods without HTTP annotations
+		s.skipMethod(method, "no google.api.http annotation")
+		return nil
+	}
+	if problem != "" {
+		switch s.invalidHTTPRules {
+		case invalidRulesFail:
+			return fmt.Errorf("%s: %s", grpcgen.RPCName(method), problem)
+		case invalidRulesSkip:
+			s.skipMethod(method, problem)
+			return nil
+		}
+		s.tracef("method %s: placeholder request, %s", grpcgen.RPCName(method), problem)
+	}
+
+	// Extract path parameters from URL (e.g., {user_id}, {name})
+	pathParams := extractPathParams(path)
+
+	filename := fmt.Sprintf("%s%s/%s", prefix, s.methodFolder(service, method), s.requestFileName(method, httpMethod))
+	s.tracef("method %s: %s %s, written to %s", grpcgen.RPCName(method), strings.ToUpper(httpMethod), path, filename)
+	w := s.newBruWriter(gen.NewGeneratedFile(filename, ""))
+	s.recordRequest(prefix, filename, method, httpMethod, path)
+
+	s.queueRequest(func() error {
+		s.writeBrunoRequest(w, service, method, httpRule, httpMethod, path, pathParams, problem)
+		return nil
+	})
+	return nil
+}
+
+// writeBrunoRequest writes the content of the request file of an HTTP rule.
+// problem tells what is wrong with an invalid rule a placeholder stands in for.
+func (s *state) writeBrunoRequest(w *bruWriter, service *protogen.Service, method *protogen.Method, httpRule *annotations.HttpRule, httpMethod string, path string, pathParams []string, problem string) {
+	// Generate Bruno file format
+	w.open("meta")
+	w.entry("name", s.requestName(method, httpMethod))
+	w.entry("type", "http")
+	w.entry("seq", s.methodSeq(method, true))
+	generateMetaTags(w, method)
+	w.close()
+	// Path parameters become request variables so their values can be edited in one place
+	chainedPath, chainedVars := s.chainResourcePath(service, method, path)
+	urlPath, pathVars := httpgen.PathVariables(chainedPath)
+	pathVars = append(pathVars, chainedVars...)
+	w.open(httpMethod)
+	w.entry("url", s.baseURLRef(), urlPath)
+	w.entry("body", "none")
+	// Method overrides and documented OpenAPI security take precedence over the configured auth
+	authOverride := methodAuthOverride(method)
+	var openAPI *openAPIAuth
+	if s.openAPISecurity && authOverride == "" {
+		openAPI = s.methodOpenAPIAuth(method)
+	}
+
+	// Add auth inheritance if collection has auth configured
+	if authOverride != "" {
+		w.entry("auth", authOverride)
+	} else if openAPI != nil {
+		w.entry("auth", openAPI.mode)
+	} else if s.collectionAuthMode != "" {
+		w.entry("auth", "inherit")
+	} else if s.requestAuthMode != "" {
+		w.entry("auth", s.requestAuthMode)
+	}
+	w.close()
+
+	// Determine which fields should be query params vs body
+	queryFields, bodyFields := s.classifyFields(method, httpRule, httpMethod, pathParams)
+
+	// Collect request headers and the pre-request script from the enabled features
+	headers := s.requestHeaders(service, method, httpMethod)
+	var preRequestScript [][]string
+	if s.idempotencyKey && (httpMethod == "post" || httpMethod == "put") {
+		preRequestScript = append(preRequestScript, s.requestScript(s.idempotencyKeyHelper()))
+	}
+	if s.csrfEndpoint != "" && httpMethod != "get" {
+		preRequestScript = append(preRequestScript, s.requestScript(s.csrfTokenHelper()))
+	}
+	if s.conditionalRead(method, httpMethod) {
+		preRequestScript = append(preRequestScript, s.conditionalHeadersScript(method))
+	}
+	// Signing runs last so it covers the headers set above
+	if s.methodHMACSign(method) {
+		preRequestScript = append(preRequestScript, s.requestScript(s.hmacSignHelper()))
+	}
+
+	// Generate query parameters section
+	if len(queryFields) > 0 {
+		w.open("params:query")
+		for _, param := range s.queryExamples(queryFields) {
+			w.entry(param[0], param[1])
+		}
+		w.close()
+	}
+
+	// Add headers section
+	if len(headers) > 0 || s.insertionPoints {
+		w.open("headers")
+		for _, header := range headers {
+			w.entry(header[0], header[1])
+		}
+		w.close()
+	}
+
+	// Add per-request auth block unless the collection provides auth
+	if authOverride != "" {
+		if authOverride != "none" && authOverride != "inherit" {
+			s.generateAuthBlock(w, authOverride)
+		}
+	} else if openAPI != nil {
+		if openAPI.mode != "none" {
+			w.open("auth:" + openAPI.mode)
+			for _, entry := range openAPI.entries {
+				w.entry(entry[0], entry[1])
+			}
+			w.close()
+		}
+	} else if s.collectionAuthMode == "" && s.requestAuthMode != "" {
+		s.generateAuthBlock(w, s.requestAuthMode)
+	}
+
+	// Add request body if needed
+	bodyJSON := s.requestBody(method, httpRule, bodyFields)
+	if bodyJSON != "" {
+		w.open("body:json")
+		w.text(bodyJSON)
+		w.close()
+	}
+
+	generatePathVars(w, pathVars)
+
+	// Smoke test assertions checked by bru run
+	if s.assertions {
+		w.open("assert")
+		w.entry("res.status", "eq ", s.assertStatus)
+		if s.maxResponseTime >= 0 {
+			w.entry("res.responseTime", "lte ", strconv.Itoa(s.maxResponseTime))
+		}
+		w.close()
+	}
+
+	// Add the request's pre-request script
+	s.generateScriptBlock(w, "script:pre-request", preRequestScript)
+	var postResponseScript [][]string
+	if script := s.resourceCaptureScript(method); script != nil {
+		postResponseScript = append(postResponseScript, script)
+	}
+	if script := s.etagCaptureScript(service, method); script != nil {
+		postResponseScript = append(postResponseScript, script)
+	}
+	if s.conditionalRead(method, httpMethod) {
+		postResponseScript = append(postResponseScript, s.validatorCaptureScript(method))
+	}
+	if s.maxRetries > 0 {
+		postResponseScript = append(postResponseScript, s.requestScript(s.retryHelper()))
+	}
+	s.generateScriptBlock(w, "script:post-response", postResponseScript)
+	var tests [][]string
+	if s.schemaTests {
+		if script := schemaTestScript(method); script != nil {
+			tests = append(tests, script)
+		}
+	}
+	if s.validationTests {
+		if script := validationTestScript(method); script != nil {
+			tests = append(tests, script)
+		}
+	}
+	s.generateScriptBlock(w, "tests", tests)
+	s.generateRequestDocs(w, method, httpgen.InvalidRuleNotice(problem), s.conditionalDocs(method, httpMethod), errorDocs(method), s.curlCommand(httpMethod, path, queryFields, headers, s.exportAuthMode(method), bodyJSON))
+	s.generateSettingsBlock(w)
+}
+
+// generateSettingsBlock writes the request settings configured by the timeout
+// and redirect options, if any
+func (s *state) generateSettingsBlock(w *bruWriter) {
+	if s.requestTimeout < 0 && s.followRedirects == "" && s.maxRedirects < 0 {
+		return
+	}
+
+	w.open("settings")
+	if s.requestTimeout >= 0 {
+		w.entry("timeout", strconv.Itoa(s.requestTimeout))
+	}
+	if s.followRedirects != "" {
+		w.entry("followRedirects", s.followRedirects)
+	}
+	if s.maxRedirects >= 0 {
+		w.entry("maxRedirects", strconv.Itoa(s.maxRedirects))
+	}
+	w.close()
+}
+
+// generateAuthBlock writes the auth block for a bearer, apikey or basic auth
+// mode, which has the same form in collection.bru and in individual requests
+func (s *state) generateAuthBlock(w *bruWriter, authMode string) {
+	switch authMode {
+	case "bearer":
+		w.open("auth:bearer")
+		w.entry("token", s.credentialRef("token"))
+		w.close()
+	case "apikey":
+		w.open("auth:apikey")
+		w.entry("key", s.apiKeyName)
+		w.entry("value", s.credentialRef("api_key"))
+		w.entry("placement", s.apiKeyPlacement)
+		w.close()
+	case "basic":
+		w.open("auth:basic")
+		w.entry("username", s.credentialRef("username"))
+		w.entry("password", s.credentialRef("password"))
+		w.close()
+	}
+}
+
+// extractPathParams extracts parameter names from a URL path
+// Examples:
+//
+//	"/v1/users/{user_id}/posts/{post_id}" -> ["user_id", "post_id"]
+//	"/v1alpha1/{name=environments/*/contact}" -> ["name"]
+//	"/v1alpha1/{parent=accounts/*}/environments" -> ["parent"]
+func extractPathParams(path string) []string {
+	var params []string
+	start := -1
+
+	for i, ch := range path {
+		if ch == '{' {
+			start = i + 1
+		} else if ch == '}' && start != -1 {
+			param := path[start:i]
+			// Handle resource patterns like {name=environments/*/contact}
+			// Extract just the parameter name before the '='
+			if idx := strings.Index(param, "="); idx != -1 {
+				param = param[:idx]
+			}
+			params = append(params, param)
+			start = -1
+		}
+	}
+
+	return params
+}
+
+// isPathParam checks if a field name matches any path parameter
+func isPathParam(fieldName string, pathParams []string) bool {
+	for _, param := range pathParams {
+		if fieldName == param {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *state) generateGrpcRequest(gen *protogen.Plugin, service *protogen.Service, method *protogen.Method, file *protogen.File, prefix string) error {
+	// Generate gRPC .bru file in a gRPC subfolder
+	filename := fmt.Sprintf("%s%s/%s", prefix, s.grpcRequestFolder(s.methodFolder(service, method)), s.requestFileName(method, "grpc"))
+	s.tracef("method %s: gRPC, written to %s", grpcgen.RPCName(method), filename)
+	w := s.newBruWriter(gen.NewGeneratedFile(filename, ""))
+	s.recordRequest(prefix, filename, method, "grpc", "")
+
+	// Construct the full gRPC method name: package.Service/Method
+	grpcMethod := fmt.Sprintf("%s.%s/%s", file.Desc.Package(), service.Desc.Name(), method.Desc.Name())
+
+	s.queueRequest(func() error {
+		return s.writeGrpcRequest(w, method, file, grpcMethod)
+	})
+	return nil
+}
+
+// writeGrpcRequest writes the content of the gRPC request file of a method
+func (s *state) writeGrpcRequest(w *bruWriter, method *protogen.Method, file *protogen.File, grpcMethod string) error {
+	if s.brunoVersion == brunoVersion2 {
+		return s.generateGrpcRequestV2(w, method, file, grpcMethod)
+	}
+
+	// Get proto file path relative to workspace
+	protoFilePath := file.Desc.Path()
+
+	// Generate Bruno gRPC file format
+	w.open("meta")
+	w.entry("name", s.requestName(method, "grpc"))
+	w.entry("type", "grpc")
+	w.entry("seq", s.methodSeq(method, false))
+	generateMetaTags(w, method)
+	w.close()
+	w.open("grpc")
+	w.entry("url", s.varRef("grpc_url"))
+	w.entry("method", grpcMethod)
+	s.generateGrpcAuth(w, method)
+	w.close()
+	s.generateMetadataBlock(w, method)
+	if methodAuthOverride(method) == "basic" {
+		s.generateAuthBlock(w, "basic")
+	}
+	w.open("body")
+	// Generate example JSON from the request message
+	w.text(s.exampleJSON(method, method.Input))
+	w.close()
+	w.open("script:pre-request")
+	w.text("// Proto file: " + protoFilePath)
+	w.close()
+	s.generateScriptBlock(w, "script:post-response", nil)
+	s.generateScriptBlock(w, "tests", nil)
+	s.generateRequestDocs(w, method, nil)
+
+	return nil
+}
+
+// generateGrpcRequestV2 writes a gRPC request using the Bruno 2.x syntax,
+// which expects a leading slash on the method, an explicit method type and
+// the message body inside a body:grpc block
+func (s *state) generateGrpcRequestV2(w *bruWriter, method *protogen.Method, file *protogen.File, grpcMethod string) error {
+	w.open("meta")
+	w.entry("name", s.requestName(method, "grpc"))
+	w.entry("type", "grpc")
+	w.entry("seq", s.methodSeq(method, false))
+	generateMetaTags(w, method)
+	w.close()
+	w.open("grpc")
+	w.entry("url", s.varRef("grpc_url"))
+	w.entry("method", "/", grpcMethod)
+	w.entry("body", "grpc")
+	s.generateGrpcAuth(w, method)
+	w.entry("methodType", grpcgen.MethodType(method))
+	w.close()
+	s.generateMetadataBlock(w, method)
+	if methodAuthOverride(method) == "basic" {
+		s.generateAuthBlock(w, "basic")
+	}
+	w.open("body:grpc")
+	w.entry("name", "message 1")
+	w.entry("content", s.exampleJSON(method, method.Input))
+	w.close()
+	w.open("script:pre-request")
+	w.text("// Proto file: " + file.Desc.Path())
+	w.close()
+	s.generateScriptBlock(w, "script:post-response", nil)
+	s.generateScriptBlock(w, "tests", nil)
+	s.generateRequestDocs(w, method, nil)
+
+	return nil
+}
+
+// exampleJSON returns the example body of a message in a request of a method,
+// within the max_example_depth and max_example_size limits
+func (s *state) exampleJSON(method *protogen.Method, msg *protogen.Message) string {
+	body := examples.JSON(msg, s.exampleLimits)
+	if strings.Contains(body, strconv.Quote(examples.TruncatedKey)) {
+		s.tracef("method %s: example body of %s cut at %d bytes", grpcgen.RPCName(method), msg.Desc.FullName(), s.exampleLimits.Size)
+	}
+	return body
+}
@@ -0,0 +1,123 @@
+package brunogen
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestOptionsParams(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want []string
+	}{
+		{
+			name: "empty options keep the defaults",
+			opts: Options{Params: []string{"mode=grpc"}},
+			want: nil,
+		},
+		{
+			name: "typed options",
+			opts: Options{Mode: "http", CollectionName: "My API", WriteMode: writeModeMerge},
+			want: []string{"mode=http", "collection_name=My API", "write_mode=merge"},
+		},
+		{
+			name: "repeatable options",
+			opts: Options{IncludeServices: []string{"Users", "Admin"}, Headers: []string{"X-Tenant: acme"}},
+			want: []string{"include_services=Users", "include_services=Admin", "header=X-Tenant: acme"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.params(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("params() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewAppliesParamsAfterTypedOptions(t *testing.T) {
+	g, err := New(Options{Mode: "http", Params: []string{"mode=grpc"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := g.params, []string{"mode=http", "mode=grpc"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("params = %q, want %q", got, want)
+	}
+	if _, err := New(Options{Params: []string{"no_such_option=1"}}); err == nil {
+		t.Error("New() with an unknown option: got no error")
+	}
+}
+
+func TestBrunoConfigEscaping(t *testing.T) {
+	root := `C:\protos\"api"`
+	for _, version := range []string{brunoVersion1, brunoVersion2} {
+		t.Run("bruno_version="+version, func(t *testing.T) {
+			g, err := New(Options{BrunoVersion: version, Params: []string{"proto_root=" + root}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := g.Run(testRequest())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var content string
+			for _, file := range resp.File {
+				if file.GetName() == "bruno.json" {
+					content = file.GetContent()
+				}
+			}
+			var config struct {
+				Protobuf struct {
+					Proto struct {
+						Root string `json:"root"`
+					} `json:"proto"`
+					ImportPaths []struct {
+						Path string `json:"path"`
+					} `json:"importPaths"`
+				} `json:"protobuf"`
+			}
+			if err := json.Unmarshal([]byte(content), &config); err != nil {
+				t.Fatalf("bruno.json is not valid JSON: %v\n%s", err, content)
+			}
+			got := config.Protobuf.Proto.Root
+			if version == brunoVersion2 {
+				got = config.Protobuf.ImportPaths[0].Path
+			}
+			if got != root {
+				t.Errorf("proto root = %q, want %q", got, root)
+			}
+		})
+	}
+}
+
+func TestClientCertificatesEscaping(t *testing.T) {
+	cert, key := `certs\"dev"\client.crt`, `certs\client.key`
+	g, err := New(Options{Params: []string{"mtls=true", "mtls_cert=" + cert, "mtls_key=" + key, "dev_url=https://api.dev.example.com"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := g.Run(testRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var content string
+	for _, file := range resp.File {
+		if file.GetName() == "bruno.json" {
+			content = file.GetContent()
+		}
+	}
+	var config struct {
+		ClientCertificates clientCertificates `json:"clientCertificates"`
+	}
+	if err := json.Unmarshal([]byte(content), &config); err != nil {
+		t.Fatalf("bruno.json is not valid JSON: %v\n%s", err, content)
+	}
+	want := []clientCertificate{
+		{Domain: "api.dev.example.com", Type: "cert", CertFilePath: cert, KeyFilePath: key},
+	}
+	if got := config.ClientCertificates.Certs; !reflect.DeepEqual(got, want) {
+		t.Errorf("certs = %+v, want %+v", got, want)
+	}
+}
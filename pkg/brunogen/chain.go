@@ -0,0 +1,176 @@
+package brunogen
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/httpgen"
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/naming"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+)
+
+// methodResource splits standard method names like "GetUser" into their verb
+// and resource. It returns empty strings for other methods.
+func methodResource(method *protogen.Method) (verb, resource string) {
+	for _, v := range []string{"Create", "Get", "Update", "Delete"} {
+		if rest, ok := strings.CutPrefix(method.GoName, v); ok && rest != "" {
+			return v, rest
+		}
+	}
+	return "", ""
+}
+
+// resourceIDField returns the field identifying a resource in a message: its
+// "<resource>_id" or "id" field, else its AIP resource "name"
+func resourceIDField(msg *protogen.Message, resource string) *protogen.Field {
+	for _, name := range []string{naming.SnakeCase(resource) + "_id", "id", "name"} {
+		for _, field := range msg.Fields {
+			if string(field.Desc.Name()) == name {
+				return field
+			}
+		}
+	}
+	return nil
+}
+
+// resourceVar returns the variable holding the identifier of the last created
+// resource, e.g. user_id or user_name
+func (s *state) resourceVar(resource string, field *protogen.Field) string {
+	if field.Desc.Name() == "name" {
+		return s.varName(naming.SnakeCase(resource) + "_name")
+	}
+	return s.varName(naming.SnakeCase(resource) + "_id")
+}
+
+// createdResourceField returns the identifier field of the resource returned by
+// the service's HTTP Create<resource> method, or nil if there is none
+func createdResourceField(service *protogen.Service, resource string) *protogen.Field {
+	for _, method := range service.Methods {
+		if method.GoName != "Create"+resource || !proto.HasExtension(method.Desc.Options(), annotations.E_Http) {
+			continue
+		}
+		return resourceIDField(method.Output, resource)
+	}
+	return nil
+}
+
+// resourceCaptureScript returns a post-response script storing the identifier
+// of the resource created by a Create method, or nil for other methods
+func (s *state) resourceCaptureScript(method *protogen.Method) []string {
+	verb, resource := methodResource(method)
+	if verb != "Create" {
+		return nil
+	}
+	field := resourceIDField(method.Output, resource)
+	if field == nil {
+		return nil
+	}
+	return []string{
+		`const resourceID = res.body?.` + field.Desc.JSONName() + `;`,
+		`if (res.status >= 200 && res.status < 300 && resourceID) {`,
+		`  bru.setVar("` + s.resourceVar(resource, field) + `", resourceID);`,
+		`}`,
+	}
+}
+
+// chainResourcePath makes the Get, Update and Delete requests of a resource
+// address the last created one, by replacing their last path parameter with
+// the variable captured from the Create response. It also returns the example
+// value of that variable, a default so the request runs on its own before the
+// Create request.
+func (s *state) chainResourcePath(service *protogen.Service, method *protogen.Method, path string) (string, [][2]string) {
+	verb, resource := methodResource(method)
+	if verb == "" || verb == "Create" {
+		return path, nil
+	}
+	field := createdResourceField(service, resource)
+	if field == nil {
+		return path, nil
+	}
+
+	start := strings.LastIndex(path, "{")
+	end := strings.LastIndex(path, "}")
+	if start == -1 || end < start {
+		return path, nil
+	}
+
+	// Only substitute parameters naming the same identifier, e.g. {user_id},
+	// {name=users/*} or {user.name=users/*}
+	param, _, _ := strings.Cut(path[start+1:end], "=")
+	if param != string(field.Desc.Name()) && !strings.HasSuffix(param, "."+string(field.Desc.Name())) {
+		return path, nil
+	}
+	name := s.resourceVar(resource, field)
+	return path[:start] + "{{" + name + "}}" + path[end+1:], [][2]string{{name, httpgen.ExamplePath(path[start : end+1])}}
+}
+
+// workflowRanks orders standard methods the way a resource is exercised:
+// created, read, listed, updated, and deleted last
+var workflowRanks = map[string]int{"Create": 0, "Get": 1, "List": 2, "Update": 3, "Delete": 5}
+
+// customMethodRank places methods other than the standard ones before Delete
+const customMethodRank = 4
+
+// workflowRank returns the position of a method in the CRUD workflow
+func workflowRank(method *protogen.Method) int {
+	for verb, rank := range workflowRanks {
+		if strings.HasPrefix(method.GoName, verb) && len(method.GoName) > len(verb) {
+			return rank
+		}
+	}
+	return customMethodRank
+}
+
+// methodSeq returns the seq of a request within its folder. Requests are
+// numbered from 1 in the configured order; HTTP folders only count methods
+// exposed over HTTP, and group folders count the methods of every service in
+// them. With unified_folders, the HTTP and gRPC requests of a method are kept
+// side by side.
+func (s *state) methodSeq(method *protogen.Method, httpFolder bool) int {
+	members := method.Parent.Methods
+	if group, ok := s.groupMembers[method.Desc.FullName()]; ok {
+		members = group
+	} else if len(s.groupMembers) > 0 {
+		// Methods moved to group folders leave their service folder
+		members = nil
+		for _, m := range method.Parent.Methods {
+			if _, grouped := s.groupMembers[m.Desc.FullName()]; !grouped {
+				members = append(members, m)
+			}
+		}
+	}
+	var methods []*protogen.Method
+	for _, m := range members {
+		if !httpFolder || s.unifiedFolders || s.hasHTTPRule(m) {
+			methods = append(methods, m)
+		}
+	}
+
+	switch s.requestOrder {
+	case requestOrderWorkflow:
+		sort.SliceStable(methods, func(i, j int) bool {
+			return workflowRank(methods[i]) < workflowRank(methods[j])
+		})
+	case requestOrderName:
+		sort.SliceStable(methods, func(i, j int) bool {
+			return methods[i].GoName < methods[j].GoName
+		})
+	}
+
+	for i, m := range methods {
+		if m != method {
+			continue
+		}
+		switch {
+		case s.unifiedFolders && httpFolder:
+			return 2*i + 1
+		case s.unifiedFolders:
+			return 2*i + 2
+		default:
+			return i + 1
+		}
+	}
+	return 1
+}
@@ -0,0 +1,81 @@
+package brunogen
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// testChainRequest returns a request with the CreateUser and GetUser methods
+// of a user resource, exposed over HTTP
+func testChainRequest() *pluginpb.CodeGeneratorRequest {
+	message := func(name string) *descriptorpb.DescriptorProto {
+		return &descriptorpb.DescriptorProto{
+			Name: proto.String(name),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:     proto.String("user_id"),
+				JsonName: proto.String("userId"),
+				Number:   proto.Int32(1),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			}},
+		}
+	}
+	method := func(name string, input string, rule *annotations.HttpRule) *descriptorpb.MethodDescriptorProto {
+		options := &descriptorpb.MethodOptions{}
+		proto.SetExtension(options, annotations.E_Http, rule)
+		return &descriptorpb.MethodDescriptorProto{
+			Name:       proto.String(name),
+			InputType:  proto.String(".example.v1." + input),
+			OutputType: proto.String(".example.v1.User"),
+			Options:    options,
+		}
+	}
+	file := &descriptorpb.FileDescriptorProto{
+		Name:        proto.String("example/v1/user.proto"),
+		Package:     proto.String("example.v1"),
+		Syntax:      proto.String("proto3"),
+		Options:     &descriptorpb.FileOptions{GoPackage: proto.String("example.com/example/v1;examplev1")},
+		MessageType: []*descriptorpb.DescriptorProto{message("User"), message("GetUserRequest")},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("UserService"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				method("CreateUser", "User", &annotations.HttpRule{Pattern: &annotations.HttpRule_Post{Post: "/v1/users"}, Body: "*"}),
+				method("GetUser", "GetUserRequest", &annotations.HttpRule{Pattern: &annotations.HttpRule_Get{Get: "/v1/users/{user_id}"}}),
+			},
+		}},
+	}
+	return &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{file.GetName()},
+		ProtoFile:      []*descriptorpb.FileDescriptorProto{file},
+	}
+}
+
+func TestChainedPathVariables(t *testing.T) {
+	g, err := New(Options{Mode: "http"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := g.Run(testChainRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var getUser string
+	for _, file := range resp.File {
+		if file.GetName() == "UserService/GetUser.bru" {
+			getUser = file.GetContent()
+		}
+	}
+	for _, want := range []string{
+		"url: {{base_url}}/v1/users/{{user_id}}",
+		"vars:pre-request {\n  user_id: example_user_id\n}",
+	} {
+		if !strings.Contains(getUser, want) {
+			t.Errorf("GetUser.bru has no %q:\n%s", want, getUser)
+		}
+	}
+}
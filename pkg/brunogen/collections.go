@@ -0,0 +1,77 @@
+package brunogen
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/naming"
+)
+
+// collectionMapping groups the packages under a prefix into a named collection
+type collectionMapping struct {
+	pkg  string
+	name string
+}
+
+// collectionMapList collects repeated collection_map options of the form
+// "package.prefix=Collection Name"
+type collectionMapList []collectionMapping
+
+// String implements flag.Value
+func (m *collectionMapList) String() string {
+	var mappings []string
+	for _, mapping := range *m {
+		mappings = append(mappings, mapping.pkg+"="+mapping.name)
+	}
+	return strings.Join(mappings, ", ")
+}
+
+// Set implements flag.Value, appending one mapping per occurrence of the option
+func (m *collectionMapList) Set(value string) error {
+	value = strings.Trim(value, `"'`)
+	pkg, name, ok := strings.Cut(value, "=")
+	pkg, name = strings.TrimSpace(pkg), strings.TrimSpace(name)
+	if !ok || pkg == "" || name == "" {
+		return fmt.Errorf("invalid collection mapping %q, expected \"package.prefix=Collection Name\"", value)
+	}
+	*m = append(*m, collectionMapping{pkg: pkg, name: name})
+	return nil
+}
+
+// mappedCollection returns the collection mapping of a package: the one with
+// the longest package prefix matching whole segments, so "billing" covers
+// billing.v1 and billing.invoices.v2 but not billingx
+func (s *state) mappedCollection(pkg string) (collectionMapping, bool) {
+	var best collectionMapping
+	var found bool
+	for _, mapping := range s.collectionMap {
+		if pkg != mapping.pkg && !strings.HasPrefix(pkg, mapping.pkg+".") {
+			continue
+		}
+		if !found || len(mapping.pkg) > len(best.pkg) {
+			best, found = mapping, true
+		}
+	}
+	return best, found
+}
+
+// folder returns the collection folder of a mapping, e.g. billing_apis for
+// "Billing APIs"
+func (m collectionMapping) folder() string {
+	words := strings.FieldsFunc(strings.ToLower(m.name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return naming.SanitizeFile(strings.Join(words, "_"))
+}
+
+// mappedCollectionName returns the name of the mapped collection written under
+// a prefix, if any
+func (s *state) mappedCollectionName(prefix string) (string, bool) {
+	for _, mapping := range s.collectionMap {
+		if mapping.folder()+"/" == prefix {
+			return mapping.name, true
+		}
+	}
+	return "", false
+}
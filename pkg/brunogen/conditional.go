@@ -0,0 +1,68 @@
+package brunogen
+
+import (
+	"strings"
+
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/naming"
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// conditionalRead reports whether a request gets conditional headers: Get and
+// List methods bound to HTTP GET when conditional_requests is enabled
+func (s *state) conditionalRead(method *protogen.Method, httpMethod string) bool {
+	if !s.conditionalReads || httpMethod != "get" {
+		return false
+	}
+	verb, _ := methodResource(method)
+	return verb == "Get" || (strings.HasPrefix(method.GoName, "List") && method.GoName != "List")
+}
+
+// validatorVar returns the variable holding a validator of the last response
+// to a request, e.g. get_user_etag or list_users_last_modified
+func (s *state) validatorVar(method *protogen.Method, validator string) string {
+	return s.varName(naming.SnakeCase(method.GoName) + "_" + validator)
+}
+
+// conditionalHeadersScript returns a pre-request script revalidating the last
+// response to a request against its ETag and Last-Modified validators. Each
+// header is only set once its validator is captured, so the first request
+// does not send unresolved variables.
+func (s *state) conditionalHeadersScript(method *protogen.Method) []string {
+	return []string{
+		`const etag = bru.getVar("` + s.validatorVar(method, "etag") + `");`,
+		`if (etag) {`,
+		`  req.setHeader("If-None-Match", etag);`,
+		`}`,
+		`const lastModified = bru.getVar("` + s.validatorVar(method, "last_modified") + `");`,
+		`if (lastModified) {`,
+		`  req.setHeader("If-Modified-Since", lastModified);`,
+		`}`,
+	}
+}
+
+// validatorCaptureScript returns a post-response script storing the ETag and
+// Last-Modified headers of a successful response for the next conditional read
+func (s *state) validatorCaptureScript(method *protogen.Method) []string {
+	return []string{
+		`if (res.status === 200) {`,
+		`  bru.setVar("` + s.validatorVar(method, "etag") + `", res.getHeader("etag") || "");`,
+		`  bru.setVar("` + s.validatorVar(method, "last_modified") + `", res.getHeader("last-modified") || "");`,
+		`}`,
+	}
+}
+
+// conditionalDocs returns the docs section explaining the conditional headers
+// of a request, or nil when it has none
+func (s *state) conditionalDocs(method *protogen.Method, httpMethod string) []string {
+	if !s.conditionalRead(method, httpMethod) {
+		return nil
+	}
+	return []string{
+		"### Conditional Requests",
+		"",
+		"The `If-None-Match` and `If-Modified-Since` headers replay the `ETag` and `Last-Modified` of the last successful response.",
+		"When the resource is unchanged the server answers `304 Not Modified` with an empty body, so send the request again without",
+		"these headers to see the full response. The headers are only sent once a response is captured, so the first request",
+		"always gets a full response.",
+	}
+}
@@ -0,0 +1,71 @@
+package brunogen
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/examples"
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/httpgen"
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// bruVarPattern matches Bruno variable references such as {{token}}
+var bruVarPattern = regexp.MustCompile(`\{\{([^}]+)\}\}`)
+
+// curlCommand returns a docs code block with a copyable curl command
+// equivalent to an HTTP request, split over continuation lines. Path
+// parameters get example values and Bruno variables become shell variables,
+// e.g. {{token}} -> ${TOKEN}.
+func (s *state) curlCommand(httpMethod string, path string, queryFields []*protogen.Field, headers [][2]string, authMode string, body string) []string {
+	var query []string
+	for _, field := range queryFields {
+		query = append(query, field.Desc.JSONName()+"="+url.QueryEscape(strings.Trim(examples.FieldValue(field, s.exampleLimits), `"`)))
+	}
+
+	authHeaders, authQuery := s.authParams(authMode)
+	headers = append(append([][2]string(nil), headers...), authHeaders...)
+	for _, param := range authQuery {
+		query = append(query, param[0]+"="+param[1])
+	}
+
+	target := s.curlBaseURL + httpgen.ExamplePath(path)
+	if len(query) > 0 {
+		target += "?" + strings.Join(query, "&")
+	}
+
+	lines := []string{"curl -X " + strings.ToUpper(httpMethod) + ` "` + shellVars(target) + `"`}
+	for _, header := range headers {
+		lines = append(lines, `  -H "`+header[0]+": "+shellVars(header[1])+`"`)
+	}
+	if body != "" {
+		var compact bytes.Buffer
+		if json.Compact(&compact, []byte(body)) == nil {
+			body = compact.String()
+		}
+		lines = append(lines,
+			`  -H "Content-Type: application/json"`,
+			`  -d '`+strings.ReplaceAll(body, "'", `'\''`)+`'`,
+		)
+	}
+
+	for i := range lines[:len(lines)-1] {
+		lines[i] += ` \`
+	}
+	return append(append([]string{"```sh"}, lines...), "```")
+}
+
+// shellVars rewrites Bruno variable references as shell variables
+func shellVars(value string) string {
+	return bruVarPattern.ReplaceAllStringFunc(value, func(ref string) string {
+		name := strings.TrimSpace(ref[2 : len(ref)-2])
+		return "${" + shellVarName(strings.TrimPrefix(name, "process.env.")) + "}"
+	})
+}
+
+// shellVarName returns the shell variable of a Bruno variable, e.g. token -> TOKEN
+func shellVarName(name string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(name))
+}
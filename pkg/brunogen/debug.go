@@ -0,0 +1,21 @@
+package brunogen
+
+import (
+	"flag"
+	"fmt"
+)
+
+// tracef writes a line of the generation trace, explaining a decision such as
+// why a field became a query parameter
+func (s *state) tracef(format string, args ...any) {
+	if s.debugLog != nil {
+		fmt.Fprintf(s.debugLog, "protoc-gen-bruno: debug: "+format+"\n", args...)
+	}
+}
+
+// traceOptions writes the options that were set, with their resolved values
+func (s *state) traceOptions(flags *flag.FlagSet) {
+	flags.Visit(func(f *flag.Flag) {
+		s.tracef("option %s=%s", f.Name, f.Value)
+	})
+}
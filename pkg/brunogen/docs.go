@@ -0,0 +1,266 @@
+package brunogen
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/naming"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// generateRequestDocs writes the docs block of a request: a notice, like the
+// warning of a placeholder request, a deprecation warning, the method comments
+// and a reference table of the request message fields, followed by any extra
+// sections. Sections are separated by blank lines.
+func (s *state) generateRequestDocs(w *bruWriter, method *protogen.Method, notice []string, extra ...[]string) {
+	var sections [][]string
+	if len(notice) > 0 {
+		sections = append(sections, notice)
+	}
+	if methodDeprecated(method) {
+		sections = append(sections, []string{"> **Deprecated:** this method is retired and may be removed. Do not build new integrations on it."})
+	}
+	if comments := strings.TrimSpace(string(method.Comments.Leading)); comments != "" {
+		var lines []string
+		for _, line := range strings.Split(comments, "\n") {
+			lines = append(lines, strings.TrimPrefix(line, " "))
+		}
+		sections = append(sections, lines)
+	}
+
+	var rows []string
+	fieldReferenceRows(method.Input, "", map[protoreflect.FullName]bool{}, &rows)
+	if len(rows) > 0 {
+		table := []string{
+			"| Field | Type | Required | Description |",
+			"| --- | --- | --- | --- |",
+		}
+		sections = append(sections, append(table, rows...))
+	}
+
+	for _, section := range extra {
+		if len(section) > 0 {
+			sections = append(sections, section)
+		}
+	}
+
+	if len(sections) == 0 && !s.insertionPoints {
+		return
+	}
+
+	w.open("docs")
+	for i, section := range sections {
+		if i > 0 {
+			w.text("")
+		}
+		w.text(section...)
+	}
+	w.close()
+}
+
+// fieldReferenceRows appends a table row per field of msg, descending into
+// nested messages with dotted JSON paths. Messages already on the path are not
+// expanded again, so recursive types terminate.
+func fieldReferenceRows(msg *protogen.Message, path string, seen map[protoreflect.FullName]bool, rows *[]string) {
+	if msg == nil || seen[msg.Desc.FullName()] {
+		return
+	}
+	seen[msg.Desc.FullName()] = true
+	defer delete(seen, msg.Desc.FullName())
+
+	for _, field := range msg.Fields {
+		name := path + field.Desc.JSONName()
+		*rows = append(*rows, "| `"+name+"` | "+fieldTypeName(field)+" | "+fieldRequirement(field)+" | "+fieldDescription(field)+" |")
+
+		// Expand plain nested messages; maps, lists and well-known types are described by their type
+		if field.Desc.Message() != nil && !field.Desc.IsMap() && !field.Desc.IsList() &&
+			!strings.HasPrefix(string(field.Message.Desc.FullName()), "google.protobuf.") {
+			fieldReferenceRows(field.Message, name+".", seen, rows)
+		}
+	}
+}
+
+// fieldTypeName returns the proto type of a field, such as "string",
+// "repeated Order" or "map<string, int32>"
+func fieldTypeName(field *protogen.Field) string {
+	if field.Desc.IsMap() {
+		return "map<" + kindName(field.Desc.MapKey()) + ", " + kindName(field.Desc.MapValue()) + ">"
+	}
+	name := kindName(field.Desc)
+	if field.Desc.IsList() {
+		return "repeated " + name
+	}
+	return name
+}
+
+// kindName returns the scalar, enum or message name of a field descriptor
+func kindName(field protoreflect.FieldDescriptor) string {
+	switch field.Kind() {
+	case protoreflect.EnumKind:
+		return string(field.Enum().Name())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return string(field.Message().Name())
+	default:
+		return field.Kind().String()
+	}
+}
+
+// fieldRequirement describes a field according to its google.api.field_behavior,
+// or its presence for fields that must be set, like the LEGACY_REQUIRED ones
+func fieldRequirement(field *protogen.Field) string {
+	if field.Desc.Cardinality() == protoreflect.Required {
+		return "required"
+	}
+	for _, behavior := range fieldBehaviors(field) {
+		switch behavior {
+		case annotations.FieldBehavior_REQUIRED:
+			return "required"
+		case annotations.FieldBehavior_OUTPUT_ONLY:
+			return "output only"
+		}
+	}
+	return "optional"
+}
+
+// fieldBehaviors returns the google.api.field_behavior annotations of a field
+func fieldBehaviors(field *protogen.Field) []annotations.FieldBehavior {
+	opts := field.Desc.Options()
+	if !proto.HasExtension(opts, annotations.E_FieldBehavior) {
+		return nil
+	}
+	return proto.GetExtension(opts, annotations.E_FieldBehavior).([]annotations.FieldBehavior)
+}
+
+// fieldDescription flattens the comments of a field into a single table cell,
+// flagging deprecated fields
+func fieldDescription(field *protogen.Field) string {
+	comments := string(field.Comments.Leading)
+	if comments == "" {
+		comments = string(field.Comments.Trailing)
+	}
+	description := strings.Join(strings.Fields(comments), " ")
+	if field.Desc.Options().(*descriptorpb.FieldOptions).GetDeprecated() {
+		description = strings.TrimSpace("**Deprecated.** " + description)
+	}
+	return strings.ReplaceAll(description, "|", `\|`)
+}
+
+// methodDeprecated reports whether a method is marked with option deprecated = true
+func methodDeprecated(method *protogen.Method) bool {
+	return method.Desc.Options().(*descriptorpb.MethodOptions).GetDeprecated()
+}
+
+// requestName returns the display name of a request: the rendered
+// request_name_template, else the first sentence of the method comments, else
+// the OpenAPI v2 operation summary, else the method name
+func (s *state) requestName(method *protogen.Method, httpMethod string) string {
+	if s.nameTemplate != "" {
+		return naming.Sanitize(s.renderRequestName(method, httpMethod))
+	}
+	if sentence := firstSentence(string(method.Comments.Leading)); sentence != "" {
+		return naming.Sanitize(sentence)
+	}
+	if summary := firstSentence(methodOperation(method).GetSummary()); summary != "" {
+		return naming.Sanitize(summary)
+	}
+	return naming.Sanitize(method.GoName)
+}
+
+// firstSentence returns the first sentence of a comment on a single line,
+// without its final period
+func firstSentence(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if i := strings.Index(text, ". "); i >= 0 {
+		text = text[:i]
+	}
+	return strings.TrimSuffix(text, ".")
+}
+
+// apiVersionPattern matches version package segments such as v1, v2beta1 or v1alpha
+var apiVersionPattern = regexp.MustCompile(`^v\d+((alpha|beta)\d*)?$`)
+
+// packageAPIVersion splits a package like "acme.billing.v1" into its API name
+// ("billing") and version ("v1"); either may be empty
+func packageAPIVersion(pkg string) (api, version string) {
+	parts := strings.Split(pkg, ".")
+	for i := len(parts) - 1; i >= 0; i-- {
+		if apiVersionPattern.MatchString(parts[i]) {
+			if version == "" {
+				version = parts[i]
+			}
+			continue
+		}
+		return parts[i], version
+	}
+	return "", version
+}
+
+// requestTags returns the tags of a request: the API name from the package,
+// the service name and the API version, e.g. ["billing", "InvoiceService", "v1"]
+func requestTags(method *protogen.Method) []string {
+	var tags []string
+	api, version := packageAPIVersion(string(method.Parent.Desc.ParentFile().Package()))
+	if api != "" {
+		tags = append(tags, api)
+	}
+	tags = append(tags, method.Parent.GoName)
+	if version != "" {
+		tags = append(tags, version)
+	}
+	return tags
+}
+
+// generateMetaTags writes the tags list of a request meta block, used to
+// filter runs with bru run --tags
+func generateMetaTags(w *bruWriter, method *protogen.Method) {
+	w.list("tags", requestTags(method))
+}
+
+// requestFileName returns the .bru file name of a request, without folder.
+// With unified_folders, the protocol tells apart the requests of a method:
+// CreateUser.http.bru and CreateUser.grpc.bru.
+func (s *state) requestFileName(method *protogen.Method, httpMethod string) string {
+	ext := ".bru"
+	if s.unifiedFolders && httpMethod == "grpc" {
+		ext = ".grpc.bru"
+	} else if s.unifiedFolders {
+		ext = ".http.bru"
+	}
+	return s.requestFileStem(method, httpMethod) + ext
+}
+
+// requestFileStem returns the name of the file of a request without
+// extension, such as CreateUser, also naming the files of other formats
+func (s *state) requestFileStem(method *protogen.Method, httpMethod string) string {
+	if s.nameTemplate == "" {
+		return naming.SanitizeFile(s.fileCase(method.GoName))
+	}
+	return naming.SanitizeFile(s.renderRequestName(method, httpMethod))
+}
+
+// renderRequestName expands the request_name_template placeholders for a method
+func (s *state) renderRequestName(method *protogen.Method, httpMethod string) string {
+	api, version := packageAPIVersion(string(method.Parent.Desc.ParentFile().Package()))
+	return strings.NewReplacer(
+		"{api}", api,
+		"{service}", method.Parent.GoName,
+		"{method}", method.GoName,
+		"{method_kebab}", naming.KebabCase(method.GoName),
+		"{http_method}", httpMethod,
+		"{version}", version,
+	).Replace(s.nameTemplate)
+}
+
+// generationStamp names the plugin release and the proto files a collection
+// was generated from, so a checked-in collection tells what produced it
+func (s *state) generationStamp(protoFiles []*protogen.File) string {
+	var paths []string
+	for _, f := range protoFiles {
+		paths = append(paths, "`"+f.Desc.Path()+"`")
+	}
+	return "Generated by protoc-gen-bruno " + s.generatorVersion + " from " + strings.Join(paths, ", ") + "."
+}
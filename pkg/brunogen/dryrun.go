@@ -0,0 +1,59 @@
+package brunogen
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/httpgen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// dryRunFile is the only file written with dry_run=true
+const dryRunFile = "dry-run.json"
+
+// dryRunRequest describes a request in dry-run.json: its manifest entry and
+// the URL of the request file
+type dryRunRequest struct {
+	manifestRequest
+	URL string `json:"url"`
+}
+
+// applyDryRun replaces the generated files with a dry-run.json listing them,
+// with the requests they hold, so the effect of filter and layout options can
+// be previewed without writing a collection. Paths are final, after
+// rewrite_path, out_prefix and write_mode.
+func (s *state) applyDryRun(resp *pluginpb.CodeGeneratorResponse) error {
+	files := []string{}
+	for _, file := range resp.File {
+		files = append(files, file.GetName())
+	}
+	sort.Strings(files)
+
+	requests := []dryRunRequest{}
+	for filename, request := range s.templateRequests {
+		request.File = s.outPrefix + s.rewritePath(filename)
+		url := s.varRef("grpc_url")
+		if request.Protocol == "http" {
+			urlPath, _ := httpgen.PathVariables(request.Path)
+			url = s.baseURLRef() + urlPath
+		}
+		requests = append(requests, dryRunRequest{request, url})
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].File < requests[j].File
+	})
+
+	content, err := json.MarshalIndent(struct {
+		Files    []string        `json:"files"`
+		Requests []dryRunRequest `json:"requests"`
+	}{files, requests}, "", "  ")
+	if err != nil {
+		return err
+	}
+	resp.File = []*pluginpb.CodeGeneratorResponse_File{{
+		Name:    proto.String(s.outPrefix + dryRunFile),
+		Content: proto.String(string(content) + "\n"),
+	}}
+	return nil
+}
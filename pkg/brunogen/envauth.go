@@ -0,0 +1,148 @@
+package brunogen
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/envgen"
+)
+
+// scriptAuthModes lists the auth modes a pre-request script can apply
+var scriptAuthModes = map[string]bool{
+	"none":      true,
+	"bearer":    true,
+	"apikey":    true,
+	"basic":     true,
+	"oauth2_cc": true,
+}
+
+// parseEnvironmentAuth parses space-separated Environment=mode pairs, such as
+// "Local=none Production=oauth2_cc", ignoring unsupported modes
+func parseEnvironmentAuth(value string) map[string]string {
+	modes := make(map[string]string)
+	for _, pair := range strings.Fields(value) {
+		name, authMode, ok := strings.Cut(pair, "=")
+		if ok && scriptAuthModes[authMode] {
+			modes[name] = authMode
+		}
+	}
+	return modes
+}
+
+// environmentAuthMode returns the auth mode used in an environment
+func (s *state) environmentAuthMode(env string) string {
+	if authMode, ok := s.envAuthModes[env]; ok {
+		return authMode
+	}
+	return s.envAuthDefault
+}
+
+// environmentAuthVars returns the credentials needed by the auth mode of an environment
+func (s *state) environmentAuthVars(env envgen.Environment) []environmentVar {
+	switch s.environmentAuthMode(env.Name) {
+	case "bearer":
+		return []environmentVar{{name: s.varName("token"), secret: true, credential: true}}
+	case "apikey":
+		return []environmentVar{{name: s.varName("api_key"), secret: true, credential: true}}
+	case "basic":
+		return []environmentVar{
+			{name: s.varName("username"), credential: true},
+			{name: s.varName("password"), secret: true, credential: true},
+		}
+	case "oauth2_cc":
+		return []environmentVar{
+			{name: s.varName("oauth2_token_url"), value: s.oauth2TokenURL},
+			{name: s.varName("oauth2_client_id"), credential: true},
+			{name: s.varName("oauth2_client_secret"), secret: true, credential: true},
+		}
+	}
+	return nil
+}
+
+// environmentAuthScript returns a script applying the auth mode of the selected
+// environment to each request. Only the modes in use get a branch.
+func (s *state) environmentAuthScript(environments []envgen.Environment) []string {
+	used := map[string]bool{}
+	var pairs []string
+	for _, env := range environments {
+		authMode := s.environmentAuthMode(env.Name)
+		used[authMode] = true
+		pairs = append(pairs, strconv.Quote(env.Name)+": "+strconv.Quote(authMode))
+	}
+
+	lines := []string{
+		`const authMode = { ` + strings.Join(pairs, ", ") + ` }[bru.getEnvName()] || ` + strconv.Quote(s.envAuthDefault) + `;`,
+	}
+	opened := false
+	branch := func(authMode string, body ...string) {
+		if !used[authMode] {
+			return
+		}
+		if opened {
+			lines[len(lines)-1] = `} else if (authMode === "` + authMode + `") {`
+		} else {
+			lines = append(lines, `if (authMode === "`+authMode+`") {`)
+			opened = true
+		}
+		for _, line := range body {
+			lines = append(lines, "  "+line)
+		}
+		lines = append(lines, "}")
+	}
+
+	branch("bearer",
+		`req.setHeader("Authorization", "Bearer " + bru.interpolate("`+s.credentialRef("token")+`"));`,
+	)
+	if s.apiKeyPlacement == "queryparams" {
+		branch("apikey",
+			`const url = new URL(bru.interpolate(req.getUrl()));`,
+			`url.searchParams.set(`+strconv.Quote(s.apiKeyName)+`, bru.interpolate("`+s.credentialRef("api_key")+`"));`,
+			`req.setUrl(url.toString());`,
+		)
+	} else {
+		branch("apikey",
+			`req.setHeader(`+strconv.Quote(s.apiKeyName)+`, bru.interpolate("`+s.credentialRef("api_key")+`"));`,
+		)
+	}
+	branch("basic",
+		`const credentials = bru.interpolate("`+s.credentialRef("username")+`:`+s.credentialRef("password")+`");`,
+		`req.setHeader("Authorization", "Basic " + Buffer.from(credentials).toString("base64"));`,
+	)
+	branch("oauth2_cc",
+		`let token = bru.getVar("`+s.varName("oauth2_access_token")+`");`,
+		`if (!token) {`,
+		`  const axios = require("axios");`,
+		`  const res = await axios.post(bru.interpolate("`+s.varRef("oauth2_token_url")+`"), new URLSearchParams({`,
+		`    grant_type: "client_credentials",`,
+		`    client_id: bru.interpolate("`+s.credentialRef("oauth2_client_id")+`"),`,
+		`    client_secret: bru.interpolate("`+s.credentialRef("oauth2_client_secret")+`"),`,
+		`    scope: `+strconv.Quote(s.oauth2Scopes)+`,`,
+		`  }));`,
+		`  token = res.data.access_token;`,
+		`  bru.setVar("`+s.varName("oauth2_access_token")+`", token);`,
+		`}`,
+		`req.setHeader("Authorization", "Bearer " + token);`,
+	)
+
+	return lines
+}
+
+// generateEnvironmentAuthDocs documents the auth mode of each environment in
+// the collection docs
+func (s *state) generateEnvironmentAuthDocs(w *bruWriter, environments []envgen.Environment) {
+	w.text(
+		"## Authentication",
+		"",
+		"Auth depends on the selected environment and is applied by the collection pre-request script.",
+		"",
+		"| Environment | Auth | Variables |",
+		"| --- | --- | --- |",
+	)
+	for _, env := range environments {
+		var vars []string
+		for _, v := range s.environmentAuthVars(env) {
+			vars = append(vars, "`"+v.name+"`")
+		}
+		w.text("| " + env.Name + " | " + s.environmentAuthMode(env.Name) + " | " + strings.Join(vars, ", ") + " |")
+	}
+}
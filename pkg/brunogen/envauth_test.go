@@ -0,0 +1,48 @@
+package brunogen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/envgen"
+)
+
+func TestEnvironmentAuthScriptQuoting(t *testing.T) {
+	tests := []struct {
+		name      string
+		placement string
+		want      []string
+	}{
+		{
+			name:      "api key header",
+			placement: "header",
+			want: []string{
+				`}[bru.getEnvName()] || "none";`,
+				`req.setHeader("X-\"Api\"-Key\\", bru.interpolate("{{api_key}}"));`,
+				`scope: "read \"all\" \\ write",`,
+			},
+		},
+		{
+			name:      "api key query parameter",
+			placement: "queryparams",
+			want: []string{
+				`url.searchParams.set("X-\"Api\"-Key\\", bru.interpolate("{{api_key}}"));`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newState()
+			s.envAuthModes = parseEnvironmentAuth("Local=apikey Development=oauth2_cc")
+			s.apiKeyName = `X-"Api"-Key\`
+			s.apiKeyPlacement = tt.placement
+			s.oauth2Scopes = `read "all" \ write`
+			script := strings.Join(s.environmentAuthScript([]envgen.Environment{{Name: "Local"}, {Name: "Development"}}), "\n")
+			for _, want := range tt.want {
+				if !strings.Contains(script, want) {
+					t.Errorf("script has no %s:\n%s", want, script)
+				}
+			}
+		})
+	}
+}
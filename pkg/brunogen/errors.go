@@ -0,0 +1,76 @@
+package brunogen
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// grpcCodes maps HTTP statuses to the gRPC codes grpc-gateway translates them
+// from. Statuses several codes share take the most common one: 400 is also
+// FAILED_PRECONDITION and OUT_OF_RANGE, and 409 is also ABORTED.
+var grpcCodes = map[int]int{
+	400: 3,  // INVALID_ARGUMENT
+	401: 16, // UNAUTHENTICATED
+	403: 7,  // PERMISSION_DENIED
+	404: 5,  // NOT_FOUND
+	409: 6,  // ALREADY_EXISTS
+	429: 8,  // RESOURCE_EXHAUSTED
+	499: 1,  // CANCELLED
+	500: 13, // INTERNAL
+	501: 12, // UNIMPLEMENTED
+	503: 14, // UNAVAILABLE
+	504: 4,  // DEADLINE_EXCEEDED
+}
+
+// errorDocs returns the docs section describing the error bodies of an HTTP
+// request: the grpc-gateway status JSON, then an example per error response
+// documented with openapiv2_operation, using the response schema reference as
+// the error detail type
+func errorDocs(method *protogen.Method) []string {
+	lines := []string{
+		"### Errors",
+		"",
+		"Failed requests return a status body with the gRPC code, a message and typed error details:",
+		"",
+		"```json",
+		"{",
+		`  "code": 5,`,
+		`  "message": "not found",`,
+		`  "details": [{ "@type": "type.googleapis.com/google.rpc.ErrorInfo" }]`,
+		"}",
+		"```",
+	}
+
+	responses := methodOperation(method).GetResponses()
+	var statuses []int
+	for key := range responses {
+		if status, err := strconv.Atoi(key); err == nil && status >= 400 {
+			statuses = append(statuses, status)
+		}
+	}
+	sort.Ints(statuses)
+
+	for _, status := range statuses {
+		response := responses[strconv.Itoa(status)]
+		code, ok := grpcCodes[status]
+		if !ok {
+			code = 2 // UNKNOWN
+		}
+		details := "[]"
+		if ref := strings.TrimPrefix(response.GetSchema().GetJsonSchema().GetRef(), "."); ref != "" {
+			details = `[{ "@type": "type.googleapis.com/` + ref + `" }]`
+		}
+		lines = append(lines,
+			"",
+			"**"+strconv.Itoa(status)+"** "+response.GetDescription(),
+			"",
+			"```json",
+			`{ "code": `+strconv.Itoa(code)+`, "message": `+strconv.Quote(response.GetDescription())+`, "details": `+details+` }`,
+			"```",
+		)
+	}
+	return lines
+}
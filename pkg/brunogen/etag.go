@@ -0,0 +1,58 @@
+package brunogen
+
+import (
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/naming"
+)
+
+// resourceHasETag reports whether the service's HTTP Get<resource> method
+// returns a resource with an AIP-154 etag field
+func resourceHasETag(service *protogen.Service, resource string) bool {
+	for _, method := range service.Methods {
+		if method.GoName != "Get"+resource || !proto.HasExtension(method.Desc.Options(), annotations.E_Http) {
+			continue
+		}
+		for _, field := range method.Output.Fields {
+			if field.Desc.Name() == "etag" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// etagVar returns the variable holding the last etag read for a resource,
+// e.g. user_etag
+func (s *state) etagVar(resource string) string {
+	return s.varName(naming.SnakeCase(resource) + "_etag")
+}
+
+// etagCaptureScript returns a post-response script storing the etag returned
+// by a Get or Update method, from the ETag header or the resource's etag field,
+// so the next If-Match sends the version last read or written. It returns nil
+// for other methods and resources without etags.
+func (s *state) etagCaptureScript(service *protogen.Service, method *protogen.Method) []string {
+	verb, resource := methodResource(method)
+	if (verb != "Get" && verb != "Update") || !resourceHasETag(service, resource) {
+		return nil
+	}
+	return []string{
+		`const etag = res.getHeader("etag") || res.body?.etag;`,
+		`if (res.status >= 200 && res.status < 300 && etag) {`,
+		`  bru.setVar("` + s.etagVar(resource) + `", etag);`,
+		`}`,
+	}
+}
+
+// etagHeader returns the If-Match header sending the captured etag with Update
+// and Delete requests, so they only apply to the version last read
+func (s *state) etagHeader(service *protogen.Service, method *protogen.Method) ([2]string, bool) {
+	verb, resource := methodResource(method)
+	if (verb != "Update" && verb != "Delete") || !resourceHasETag(service, resource) {
+		return [2]string{}, false
+	}
+	return [2]string{"If-Match", "{{" + s.etagVar(resource) + "}}"}, true
+}
@@ -0,0 +1,84 @@
+package brunogen
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/grpcgen"
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// patternList holds the regular expressions of a repeatable filter option
+type patternList []*regexp.Regexp
+
+// String implements flag.Value
+func (p *patternList) String() string {
+	var patterns []string
+	for _, re := range *p {
+		patterns = append(patterns, re.String())
+	}
+	return strings.Join(patterns, " ")
+}
+
+// Set implements flag.Value, appending one pattern per occurrence of the option
+func (p *patternList) Set(value string) error {
+	re, err := regexp.Compile(value)
+	if err != nil {
+		return fmt.Errorf("invalid pattern %q: %v", value, err)
+	}
+	*p = append(*p, re)
+	return nil
+}
+
+// matches reports whether any pattern matches a name
+func (p patternList) matches(name string) bool {
+	for _, re := range p {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// selected reports whether a name passes an include and exclude filter pair:
+// it matches an include pattern, if any are set, and no exclude pattern
+func selected(name string, include, exclude patternList) bool {
+	return (len(include) == 0 || include.matches(name)) && !exclude.matches(name)
+}
+
+// filterServices returns the files with the services and methods left out by
+// the include/exclude options dropped, so every part of the generation, from
+// folders to collection names, only sees the selected ones. Methods match as
+// package.Service/Method, and services left without methods are dropped. The
+// files, services and methods are copies, leaving those of the plugin as they
+// are.
+func (s *state) filterServices(files []*protogen.File) []*protogen.File {
+	filtered := make([]*protogen.File, 0, len(files))
+	for _, f := range files {
+		file := *f
+		file.Services = nil
+		for _, service := range f.Services {
+			if !selected(string(service.Desc.FullName()), s.includeServices, s.excludeServices) {
+				s.tracef("service %s: left out by include_services/exclude_services", service.Desc.FullName())
+				continue
+			}
+			copied := *service
+			copied.Methods = nil
+			for _, method := range service.Methods {
+				if !selected(grpcgen.RPCName(method), s.includeMethods, s.excludeMethods) {
+					s.tracef("method %s: left out by include_methods/exclude_methods", grpcgen.RPCName(method))
+					continue
+				}
+				m := *method
+				m.Parent = &copied
+				copied.Methods = append(copied.Methods, &m)
+			}
+			if len(copied.Methods) > 0 {
+				file.Services = append(file.Services, &copied)
+			}
+		}
+		filtered = append(filtered, &file)
+	}
+	return filtered
+}
@@ -0,0 +1,129 @@
+package brunogen
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/grpcgen"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// testRequest returns a request generating a file with a UserService and an
+// AdminService of a few methods each
+func testRequest() *pluginpb.CodeGeneratorRequest {
+	method := func(name string) *descriptorpb.MethodDescriptorProto {
+		return &descriptorpb.MethodDescriptorProto{
+			Name:       proto.String(name),
+			InputType:  proto.String(".example.v1.Empty"),
+			OutputType: proto.String(".example.v1.Empty"),
+		}
+	}
+	file := &descriptorpb.FileDescriptorProto{
+		Name:        proto.String("example/v1/user.proto"),
+		Package:     proto.String("example.v1"),
+		Syntax:      proto.String("proto3"),
+		Options:     &descriptorpb.FileOptions{GoPackage: proto.String("example.com/example/v1;examplev1")},
+		MessageType: []*descriptorpb.DescriptorProto{{Name: proto.String("Empty")}},
+		Service: []*descriptorpb.ServiceDescriptorProto{
+			{Name: proto.String("UserService"), Method: []*descriptorpb.MethodDescriptorProto{method("GetUser"), method("DeleteUser")}},
+			{Name: proto.String("AdminService"), Method: []*descriptorpb.MethodDescriptorProto{method("Ban")}},
+		},
+	}
+	return &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{file.GetName()},
+		ProtoFile:      []*descriptorpb.FileDescriptorProto{file},
+	}
+}
+
+// testPlugin returns a plugin of the test request
+func testPlugin(t *testing.T) *protogen.Plugin {
+	t.Helper()
+	gen, err := protogen.Options{}.New(testRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gen
+}
+
+// methodNames returns the methods of the files as package.Service/Method
+func methodNames(files []*protogen.File) []string {
+	var names []string
+	for _, f := range files {
+		for _, service := range f.Services {
+			for _, method := range service.Methods {
+				names = append(names, grpcgen.RPCName(method))
+			}
+		}
+	}
+	return names
+}
+
+func TestFilterServices(t *testing.T) {
+	tests := []struct {
+		name                             string
+		includeServices, excludeServices []string
+		includeMethods, excludeMethods   []string
+		want                             []string
+	}{
+		{
+			name: "no filters",
+			want: []string{"example.v1.UserService/GetUser", "example.v1.UserService/DeleteUser", "example.v1.AdminService/Ban"},
+		},
+		{
+			name:            "included services",
+			includeServices: []string{`\.UserService$`},
+			want:            []string{"example.v1.UserService/GetUser", "example.v1.UserService/DeleteUser"},
+		},
+		{
+			name:            "excluded services",
+			excludeServices: []string{`UserService`},
+			want:            []string{"example.v1.AdminService/Ban"},
+		},
+		{
+			name:           "excluded methods",
+			excludeMethods: []string{`/Delete`},
+			want:           []string{"example.v1.UserService/GetUser", "example.v1.AdminService/Ban"},
+		},
+		{
+			name:           "services without methods are dropped",
+			includeMethods: []string{`/GetUser$`},
+			want:           []string{"example.v1.UserService/GetUser"},
+		},
+	}
+	compile := func(patterns []string) patternList {
+		var list patternList
+		for _, pattern := range patterns {
+			list = append(list, regexp.MustCompile(pattern))
+		}
+		return list
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := testPlugin(t)
+			s := newState()
+			s.includeServices, s.excludeServices = compile(tt.includeServices), compile(tt.excludeServices)
+			s.includeMethods, s.excludeMethods = compile(tt.includeMethods), compile(tt.excludeMethods)
+
+			filtered := s.filterServices(gen.Files)
+			if got := methodNames(filtered); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterServices() = %q, want %q", got, tt.want)
+			}
+			for _, f := range filtered {
+				for _, service := range f.Services {
+					for _, method := range service.Methods {
+						if method.Parent != service {
+							t.Errorf("method %s: parent is not its filtered service", grpcgen.RPCName(method))
+						}
+					}
+				}
+			}
+			if got := len(methodNames(gen.Files)); got != 3 {
+				t.Errorf("plugin files have %d methods after filtering, want 3", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package brunogen
+
+import (
+	"fmt"
+
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/envgen"
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// Supported output formats
+const (
+	formatBruno      = "bruno"
+	formatHTTP       = "http"
+	formatHoppscotch = "hoppscotch"
+	formatK6         = "k6"
+	formatHurl       = "hurl"
+	formatScripts    = "scripts"
+)
+
+// generateFormat writes the HTTP requests of the proto files in an output
+// format other than Bruno, built from the same requests as the .bru files.
+// Shell scripts also cover the gRPC requests.
+func (s *state) generateFormat(gen *protogen.Plugin, protoFiles []*protogen.File, environments []envgen.Environment, collectionName string, mode generationMode) error {
+	var requests []*httpRequest
+	if mode.http() {
+		var err error
+		if requests, err = s.httpRequests(protoFiles); err != nil {
+			return err
+		}
+	}
+	switch s.outputFormat {
+	case formatHTTP:
+		s.generateRESTClientFiles(gen, requests, environments)
+	case formatHoppscotch:
+		return s.generateHoppscotchCollections(gen, protoFiles, requests, environments, collectionName)
+	case formatK6:
+		s.generateK6Scripts(gen, protoFiles, requests, environments, collectionName)
+	case formatHurl:
+		s.generateHurlFiles(gen, requests, environments)
+	case formatScripts:
+		s.generateShellScripts(gen, protoFiles, requests, environments, mode)
+	default:
+		return fmt.Errorf("unknown format %q", s.outputFormat)
+	}
+	return nil
+}
+
+// requestsByService groups requests by the file a service is written to, in
+// the order the services come first
+func requestsByService(requests []*httpRequest, filename func(req *httpRequest) string) ([]string, map[string][]*httpRequest) {
+	var names []string
+	groups := make(map[string][]*httpRequest)
+	for _, req := range requests {
+		name := filename(req)
+		if _, ok := groups[name]; !ok {
+			names = append(names, name)
+		}
+		groups[name] = append(groups[name], req)
+	}
+	return names, groups
+}
@@ -0,0 +1,111 @@
+package brunogen
+
+import (
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// groupFolder is a folder grouping requests of any service, such as the folder
+// of a resource with layout=resource or of a tag with layout=tag
+type groupFolder struct {
+	folder string
+	name   string
+	docs   string
+}
+
+// groupsMethods reports whether the layout groups methods in folders shared by
+// services rather than in service folders
+func (s *state) groupsMethods() bool {
+	return s.layout == layoutResource || s.layout == layoutTag
+}
+
+// findGroupFolders assigns the methods of the services to the folders of the
+// layout. Methods outside any group stay in their service folder, and so do
+// methods of different services that would write the same file in a group.
+func (s *state) findGroupFolders(files []*protogen.File, protoFiles []*protogen.File) {
+	s.groupFolders = map[protoreflect.FullName]groupFolder{}
+	s.groupMembers = map[protoreflect.FullName][]*protogen.Method{}
+
+	var group func(method *protogen.Method) (groupFolder, bool)
+	switch s.layout {
+	case layoutResource:
+		descriptors := resourceDescriptors(files)
+		group = func(method *protogen.Method) (groupFolder, bool) {
+			resourceType := methodResourceType(method)
+			if resourceType == "" {
+				return groupFolder{}, false
+			}
+			return s.newResourceFolder(resourceType, descriptors[resourceType]), true
+		}
+	case layoutTag:
+		group = s.methodTagFolder
+	default:
+		return
+	}
+
+	byFile := make(map[string][]protoreflect.FullName)
+	byFolder := make(map[string][]*protogen.Method)
+	for _, f := range protoFiles {
+		for _, service := range f.Services {
+			for _, method := range service.Methods {
+				folder, ok := group(method)
+				if !ok {
+					continue
+				}
+				s.groupFolders[method.Desc.FullName()] = folder
+				key := s.collectionPrefix(f, service) + folder.folder
+				byFolder[key] = append(byFolder[key], method)
+				byFile[key+"/"+method.GoName] = append(byFile[key+"/"+method.GoName], method.Desc.FullName())
+			}
+		}
+	}
+	for _, methods := range byFile {
+		if len(methods) > 1 {
+			for _, name := range methods {
+				delete(s.groupFolders, name)
+			}
+		}
+	}
+
+	// Number the requests of a group folder across services, in the order
+	// methods are declared
+	for _, methods := range byFolder {
+		var members []*protogen.Method
+		for _, method := range methods {
+			if _, ok := s.groupFolders[method.Desc.FullName()]; ok {
+				members = append(members, method)
+			}
+		}
+		for _, method := range members {
+			s.groupMembers[method.Desc.FullName()] = members
+		}
+	}
+}
+
+// methodFolder returns the folder holding the HTTP request of a method: its
+// group folder, if any, else its service folder
+func (s *state) methodFolder(service *protogen.Service, method *protogen.Method) string {
+	if folder, ok := s.groupFolders[method.Desc.FullName()]; ok {
+		return folder.folder
+	}
+	return s.serviceFolder(service)
+}
+
+// generateMethodFolders writes the folder.bru of the folders holding the
+// requests of a method when the layout groups methods, once per folder
+func (s *state) generateMethodFolders(gen *protogen.Plugin, service *protogen.Service, method *protogen.Method, prefix string, mode generationMode) {
+	name, docs := s.serviceDisplayName(service), string(service.Comments.Leading)
+	if folder, ok := s.groupFolders[method.Desc.FullName()]; ok {
+		name, docs = folder.name, folder.docs
+	}
+
+	folder := s.methodFolder(service, method)
+	if mode.http() && (s.hasHTTPRule(method) || s.unifiedFolders) && !s.writtenFolders[prefix+folder] {
+		s.writtenFolders[prefix+folder] = true
+		s.generateFolderBru(gen, prefix, folder, name, docs)
+	}
+	if mode.grpc() && !s.unifiedFolders && !s.writtenFolders[prefix+s.grpcFolderName(folder)] {
+		s.writtenFolders[prefix+s.grpcFolderName(folder)] = true
+		s.generateFolderBru(gen, prefix, s.grpcFolderName(folder), name+" (gRPC)", docs)
+	}
+}
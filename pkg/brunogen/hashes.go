@@ -0,0 +1,112 @@
+package brunogen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// hashesFile lists the content hash of every generated file
+const hashesFile = "hashes.json"
+
+// fileHash is the content hash of a generated file in hashes.json
+type fileHash struct {
+	File   string `json:"file"`
+	SHA256 string `json:"sha256"`
+}
+
+// contentHashes returns the SHA-256 of each generated file, sorted by path.
+// Files are hashed as generated, before write_mode merges manual edits, so
+// the hashes only change when the generated content does.
+func contentHashes(resp *pluginpb.CodeGeneratorResponse) []fileHash {
+	hashes := []fileHash{}
+	for _, file := range resp.File {
+		sum := sha256.Sum256([]byte(file.GetContent()))
+		hashes = append(hashes, fileHash{File: file.GetName(), SHA256: hex.EncodeToString(sum[:])})
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		return hashes[i].File < hashes[j].File
+	})
+	return hashes
+}
+
+// hashesResponseFile returns the hashes.json of the generated files
+func (s *state) hashesResponseFile(hashes []fileHash) (*pluginpb.CodeGeneratorResponse_File, error) {
+	content, err := json.MarshalIndent(struct {
+		Files []fileHash `json:"files"`
+	}{hashes}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &pluginpb.CodeGeneratorResponse_File{
+		Name:    proto.String(s.outPrefix + hashesFile),
+		Content: proto.String(string(content) + "\n"),
+	}, nil
+}
+
+// reportChanges compares the hashes of the generated files with the
+// hashes.json of the previous generation in out_dir, and writes the files
+// added, changed and removed since, so incremental workflows only need to
+// process those
+func (s *state) reportChanges(w io.Writer, hashes []fileHash) error {
+	if w == nil {
+		return nil
+	}
+	content, err := os.ReadFile(filepath.Join(s.outDir, filepath.FromSlash(s.outPrefix+hashesFile)))
+	if errors.Is(err, fs.ErrNotExist) {
+		fmt.Fprintf(w, "protoc-gen-bruno: no %s from a previous generation in %s, all %d files are new\n", hashesFile, s.outDir, len(hashes))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var previous struct {
+		Files []fileHash `json:"files"`
+	}
+	if err := json.Unmarshal(content, &previous); err != nil {
+		return fmt.Errorf("reading the previous %s: %w", hashesFile, err)
+	}
+
+	old := make(map[string]string)
+	for _, hash := range previous.Files {
+		old[hash.File] = hash.SHA256
+	}
+	// Changes are kind and file pairs, in file order
+	var changes [][2]string
+	for _, hash := range hashes {
+		sum, ok := old[hash.File]
+		switch {
+		case !ok:
+			changes = append(changes, [2]string{"added", hash.File})
+		case sum != hash.SHA256:
+			changes = append(changes, [2]string{"changed", hash.File})
+		}
+		delete(old, hash.File)
+	}
+	for file := range old {
+		changes = append(changes, [2]string{"removed", file})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i][1] < changes[j][1]
+	})
+
+	files := "files"
+	if len(changes) == 1 {
+		files = "file"
+	}
+	fmt.Fprintf(w, "protoc-gen-bruno: %d %s changed since the last generation\n", len(changes), files)
+	for _, change := range changes {
+		fmt.Fprintf(w, "  %s: %s\n", change[0], change[1])
+	}
+	return nil
+}
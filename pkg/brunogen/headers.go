@@ -0,0 +1,217 @@
+package brunogen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/envgen"
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/naming"
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// headerList collects repeated header options of the form "Name: value"
+type headerList [][2]string
+
+// String implements flag.Value
+func (h *headerList) String() string {
+	var headers []string
+	for _, header := range *h {
+		headers = append(headers, header[0]+": "+header[1])
+	}
+	return strings.Join(headers, ", ")
+}
+
+// Set implements flag.Value, appending one header per occurrence of the option
+func (h *headerList) Set(value string) error {
+	value = strings.Trim(value, `"'`)
+	name, headerValue, ok := strings.Cut(value, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return fmt.Errorf("invalid header %q, expected \"Name: value\"", value)
+	}
+	*h = append(*h, [2]string{name, strings.TrimSpace(headerValue)})
+	return nil
+}
+
+// has reports whether the list contains a header, ignoring case
+func (h headerList) has(name string) bool {
+	for _, header := range h {
+		if strings.EqualFold(header[0], name) {
+			return true
+		}
+	}
+	return false
+}
+
+// envHeader is a header only sent in some environments
+type envHeader struct {
+	name         string
+	value        string
+	environments []string
+}
+
+// envHeaderList collects repeated environment header options of the form
+// "Env1 Env2:Name: value"
+type envHeaderList []envHeader
+
+// String implements flag.Value
+func (h *envHeaderList) String() string {
+	var headers []string
+	for _, header := range *h {
+		headers = append(headers, strings.Join(header.environments, " ")+":"+header.name+": "+header.value)
+	}
+	return strings.Join(headers, ", ")
+}
+
+// Set implements flag.Value, appending one header per occurrence of the option
+func (h *envHeaderList) Set(value string) error {
+	value = strings.Trim(value, `"'`)
+	environments, header, ok := strings.Cut(value, ":")
+	if !ok || len(strings.Fields(environments)) == 0 {
+		return fmt.Errorf("invalid environment header %q, expected \"Env1 Env2:Name: value\"", value)
+	}
+	var parsed headerList
+	if err := parsed.Set(header); err != nil {
+		return err
+	}
+	*h = append(*h, envHeader{name: parsed[0][0], value: parsed[0][1], environments: strings.Fields(environments)})
+	return nil
+}
+
+// headerVar returns the environment variable holding the value of an environment header
+func (s *state) headerVar(h envHeader) string {
+	return s.varName("header_" + naming.SnakeCase(h.name))
+}
+
+// environmentHeaderVars returns the environment header variables of an
+// environment; environments the header is not enabled for get an empty value
+func (s *state) environmentHeaderVars(env envgen.Environment) []environmentVar {
+	var vars []environmentVar
+	for _, header := range s.envHeaders {
+		v := environmentVar{name: s.headerVar(header)}
+		for _, name := range header.environments {
+			if name == env.Name {
+				v.value = header.value
+			}
+		}
+		vars = append(vars, v)
+	}
+	return vars
+}
+
+// envHeadersScript returns a script setting each environment header whose
+// variable has a value in the selected environment
+func (s *state) envHeadersScript() []string {
+	var lines []string
+	for _, header := range s.envHeaders {
+		lines = append(lines,
+			`if (bru.getEnvVar("`+s.headerVar(header)+`")) {`,
+			`  req.setHeader("`+header.name+`", bru.getEnvVar("`+s.headerVar(header)+`"));`,
+			`}`,
+		)
+	}
+	return lines
+}
+
+// metadataKey is gRPC metadata sent through the gateway's Grpc-Metadata- header
+// prefix; without a value, it is read from an environment variable
+type metadataKey struct {
+	key   string
+	value string
+}
+
+// metadataList collects repeated gRPC metadata options of the form "key" or
+// "key: value"
+type metadataList []metadataKey
+
+// String implements flag.Value
+func (m *metadataList) String() string {
+	var keys []string
+	for _, md := range *m {
+		keys = append(keys, md.key+": "+md.value)
+	}
+	return strings.Join(keys, ", ")
+}
+
+// Set implements flag.Value, appending one key per occurrence of the option
+func (m *metadataList) Set(value string) error {
+	value = strings.Trim(value, `"'`)
+	key, mdValue, _ := strings.Cut(value, ":")
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return fmt.Errorf("invalid gRPC metadata %q, expected \"key\" or \"key: value\"", value)
+	}
+	*m = append(*m, metadataKey{key: key, value: strings.TrimSpace(mdValue)})
+	return nil
+}
+
+// metadataVar returns the environment variable holding the value of a metadata
+// key, e.g. metadata_x_tenant
+func (s *state) metadataVar(md metadataKey) string {
+	return s.varName("metadata_" + naming.SnakeCase(md.key))
+}
+
+// metadataValue returns the fixed value of a metadata key, or a reference to
+// its environment variable
+func (s *state) metadataValue(md metadataKey) string {
+	if md.value == "" {
+		return "{{" + s.metadataVar(md) + "}}"
+	}
+	return md.value
+}
+
+// metadataHeaders returns the Grpc-Metadata- headers of the configured keys,
+// which grpc-gateway forwards to the backend as incoming metadata
+func (s *state) metadataHeaders() [][2]string {
+	var headers [][2]string
+	for _, md := range s.grpcMetadata {
+		headers = append(headers, [2]string{"Grpc-Metadata-" + md.key, s.metadataValue(md)})
+	}
+	return headers
+}
+
+// generateMetadataBlock writes the metadata block of a gRPC request, sending
+// the configured keys directly, and the credentials of a bearer or apikey
+// override of the method
+func (s *state) generateMetadataBlock(w *bruWriter, method *protogen.Method) {
+	w.open("metadata")
+	for _, md := range s.grpcMetadata {
+		w.entry(strings.ToLower(md.key), s.metadataValue(md))
+	}
+	switch methodAuthOverride(method) {
+	case "bearer":
+		w.entry("authorization", "Bearer ", s.credentialRef("token"))
+	case "apikey":
+		// Metadata has no query, so the key is sent whatever its placement
+		w.entry(strings.ToLower(s.apiKeyName), s.credentialRef("api_key"))
+	}
+	w.close()
+}
+
+// generateGrpcAuth writes the auth mode of a gRPC request. Bearer and apikey
+// overrides are sent in the metadata, so the collection auth is turned off,
+// while basic credentials are left to Bruno to encode.
+func (s *state) generateGrpcAuth(w *bruWriter, method *protogen.Method) {
+	switch methodAuthOverride(method) {
+	case "none", "bearer", "apikey":
+		w.entry("auth", "none")
+	case "basic":
+		w.entry("auth", "basic")
+	default:
+		if s.collectionAuthMode != "" {
+			w.entry("auth", "inherit")
+		}
+	}
+}
+
+// environmentMetadataVars returns the variables of the metadata keys without a
+// fixed value, left empty to be filled in per environment
+func (s *state) environmentMetadataVars() []environmentVar {
+	var vars []environmentVar
+	for _, md := range s.grpcMetadata {
+		if md.value == "" {
+			vars = append(vars, environmentVar{name: s.metadataVar(md)})
+		}
+	}
+	return vars
+}
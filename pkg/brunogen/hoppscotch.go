@@ -0,0 +1,162 @@
+package brunogen
+
+import (
+	"strings"
+
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/envgen"
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// hoppCollection is a Hoppscotch collection or folder, in the version 2
+// schema of its collection export
+type hoppCollection struct {
+	V        int               `json:"v"`
+	Name     string            `json:"name"`
+	Folders  []*hoppCollection `json:"folders"`
+	Requests []*hoppRequest    `json:"requests"`
+	Auth     hoppAuth          `json:"auth"`
+	Headers  []hoppKeyValue    `json:"headers"`
+}
+
+// hoppRequest is a Hoppscotch REST request, in the version 2 schema, the
+// first with request variables
+type hoppRequest struct {
+	V                string         `json:"v"`
+	Name             string         `json:"name"`
+	Method           string         `json:"method"`
+	Endpoint         string         `json:"endpoint"`
+	Params           []hoppKeyValue `json:"params"`
+	Headers          []hoppKeyValue `json:"headers"`
+	PreRequestScript string         `json:"preRequestScript"`
+	TestScript       string         `json:"testScript"`
+	Auth             hoppAuth       `json:"auth"`
+	Body             hoppBody       `json:"body"`
+	RequestVariables []hoppKeyValue `json:"requestVariables"`
+}
+
+// hoppKeyValue is a parameter, header or variable of a Hoppscotch request
+type hoppKeyValue struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Active bool   `json:"active"`
+}
+
+// hoppAuth is the auth of a Hoppscotch collection or request. Credentials are
+// sent as headers and parameters, so requests inherit none from the
+// collection.
+type hoppAuth struct {
+	AuthType   string `json:"authType"`
+	AuthActive bool   `json:"authActive"`
+}
+
+// hoppBody is the body of a Hoppscotch request, with null fields for none
+type hoppBody struct {
+	ContentType *string `json:"contentType"`
+	Body        *string `json:"body"`
+}
+
+// hoppEnvironment is a Hoppscotch environment, as imported from JSON
+type hoppEnvironment struct {
+	V         int               `json:"v"`
+	Name      string            `json:"name"`
+	Variables []hoppEnvVariable `json:"variables"`
+}
+
+// hoppEnvVariable is a variable of a Hoppscotch environment
+type hoppEnvVariable struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Secret bool   `json:"secret"`
+}
+
+// generateHoppscotchCollections writes a Hoppscotch collection per collection,
+// with a folder per service, and its environments. Variables are written in
+// the Hoppscotch syntax, e.g. {{base_url}} -> <<base_url>>.
+func (s *state) generateHoppscotchCollections(gen *protogen.Plugin, protoFiles []*protogen.File, requests []*httpRequest, environments []envgen.Environment, customName string) error {
+	prefixes, collections := requestsByService(requests, func(req *httpRequest) string {
+		return s.collectionPrefix(req.file, req.service)
+	})
+	for _, prefix := range prefixes {
+		collection := &hoppCollection{
+			V:        2,
+			Name:     s.collectionDisplayName(protoFiles, prefix, customName),
+			Folders:  []*hoppCollection{},
+			Requests: []*hoppRequest{},
+			Auth:     hoppAuth{AuthType: "none", AuthActive: true},
+			Headers:  []hoppKeyValue{},
+		}
+		folders := make(map[*protogen.Service]*hoppCollection)
+		for _, req := range collections[prefix] {
+			folder, ok := folders[req.service]
+			if !ok {
+				folder = &hoppCollection{
+					V:        2,
+					Name:     s.serviceDisplayName(req.service),
+					Folders:  []*hoppCollection{},
+					Requests: []*hoppRequest{},
+					Auth:     hoppAuth{AuthType: "inherit", AuthActive: true},
+					Headers:  []hoppKeyValue{},
+				}
+				folders[req.service] = folder
+				collection.Folders = append(collection.Folders, folder)
+			}
+			folder.Requests = append(folder.Requests, s.newHoppRequest(req))
+		}
+		if err := writeJSONFile(gen, prefix+"hoppscotch-collection.json", collection); err != nil {
+			return err
+		}
+
+		hoppEnvironments := []hoppEnvironment{}
+		for _, env := range environments {
+			variables := []hoppEnvVariable{}
+			for _, v := range s.environmentVars(env, modeHTTP) {
+				variables = append(variables, hoppEnvVariable{Key: v.name, Value: v.value, Secret: v.secret || v.credential})
+			}
+			hoppEnvironments = append(hoppEnvironments, hoppEnvironment{V: 1, Name: env.Name, Variables: variables})
+		}
+		if err := writeJSONFile(gen, prefix+"hoppscotch-environments.json", hoppEnvironments); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newHoppRequest returns the Hoppscotch request of an HTTP request
+func (s *state) newHoppRequest(req *httpRequest) *hoppRequest {
+	r := &hoppRequest{
+		V:                "2",
+		Name:             req.name,
+		Method:           strings.ToUpper(req.verb),
+		Endpoint:         hoppVars(s.requestURL(req)),
+		Params:           hoppKeyValues(req.query),
+		Headers:          hoppKeyValues(req.headers),
+		Auth:             hoppAuth{AuthType: "inherit", AuthActive: true},
+		RequestVariables: hoppKeyValues(req.pathVars),
+	}
+	if req.problem != "" {
+		r.PreRequestScript = "// Warning: the google.api.http rule of this method is invalid: " + req.problem
+	}
+	if req.body != "" {
+		contentType, body := "application/json", req.body
+		r.Body = hoppBody{ContentType: &contentType, Body: &body}
+	}
+	return r
+}
+
+// hoppKeyValues returns name and value pairs as active Hoppscotch key values
+func hoppKeyValues(pairs [][2]string) []hoppKeyValue {
+	values := []hoppKeyValue{}
+	for _, pair := range pairs {
+		values = append(values, hoppKeyValue{Key: pair[0], Value: hoppVars(pair[1]), Active: true})
+	}
+	return values
+}
+
+// hoppVars rewrites Bruno variable references in the Hoppscotch syntax,
+// {{name}} -> <<name>>, with process.env variables as plain variables
+func hoppVars(value string) string {
+	return bruVarPattern.ReplaceAllStringFunc(value, func(ref string) string {
+		name := strings.TrimSpace(ref[2 : len(ref)-2])
+		return "<<" + strings.TrimPrefix(name, "process.env.") + ">>"
+	})
+}
@@ -0,0 +1,20 @@
+package brunogen
+
+import (
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/httpgen"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// Supported ways of handling invalid google.api.http rules
+const (
+	invalidRulesSkip        = "skip"
+	invalidRulesFail        = "fail"
+	invalidRulesPlaceholder = "placeholder"
+)
+
+// methodHTTPRule returns the google.api.http rule of a method, with
+// placeholders for invalid rules with invalid_http_rules=placeholder
+func (s *state) methodHTTPRule(method *protogen.Method) (rule *annotations.HttpRule, httpMethod string, path string, problem string, ok bool) {
+	return httpgen.MethodRule(method, httpgen.Config{Placeholders: s.invalidHTTPRules == invalidRulesPlaceholder})
+}
@@ -0,0 +1,65 @@
+package brunogen
+
+import (
+	"strings"
+
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/envgen"
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/grpcgen"
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// generateHurlFiles writes a .hurl file per HTTP request, next to where its
+// .bru file would be, asserting a 200 status. Hurl reads variables with the
+// same {{name}} syntax as Bruno, so each environment gets a variables file,
+// environments/<name>.env, for hurl --variables-file.
+func (s *state) generateHurlFiles(gen *protogen.Plugin, requests []*httpRequest, environments []envgen.Environment) {
+	for _, req := range requests {
+		filename := s.collectionPrefix(req.file, req.service) + s.methodFolder(req.service, req.method) + "/" + s.requestFileStem(req.method, req.verb) + ".hurl"
+		g := gen.NewGeneratedFile(filename, "")
+		g.P("# ", req.name)
+		g.P("# ", grpcgen.RPCName(req.method))
+		if req.problem != "" {
+			g.P("# Warning: the google.api.http rule of this method is invalid: ", req.problem)
+		}
+		g.P(strings.ToUpper(req.verb), " ", hurlVars(s.requestURL(req)))
+		for _, header := range req.headers {
+			g.P(header[0], ": ", hurlVars(header[1]))
+		}
+		if len(req.query) > 0 {
+			g.P("[QueryStringParams]")
+			for _, param := range req.query {
+				g.P(param[0], ": ", hurlVars(param[1]))
+			}
+		}
+		if req.body != "" {
+			g.P(req.body)
+		}
+		g.P()
+		g.P("HTTP 200")
+	}
+
+	prefixes, collections := requestsByService(requests, func(req *httpRequest) string {
+		return s.collectionPrefix(req.file, req.service)
+	})
+	for _, prefix := range prefixes {
+		for _, env := range environments {
+			g := gen.NewGeneratedFile(prefix+"environments/"+env.Name+".env", "")
+			for _, v := range s.requestVariables(collections[prefix], []envgen.Environment{env}) {
+				g.P(v[0], "=", v[1])
+			}
+		}
+	}
+}
+
+// hurlVars rewrites references to process environment variables, which
+// Bruno reads from .env, as the Hurl variables set by HURL_ variables, e.g.
+// {{process.env.API_KEY}} -> {{API_KEY}}, set by HURL_API_KEY
+func hurlVars(value string) string {
+	return bruVarPattern.ReplaceAllStringFunc(value, func(ref string) string {
+		name := strings.TrimSpace(ref[2 : len(ref)-2])
+		if env, ok := strings.CutPrefix(name, "process.env."); ok {
+			return "{{" + env + "}}"
+		}
+		return ref
+	})
+}
@@ -0,0 +1,112 @@
+// Package envgen builds the environments of a collection, Local, Development,
+// Staging and Production, with their HTTP and gRPC endpoints.
+package envgen
+
+import (
+	"strings"
+)
+
+// Environment is a collection environment and its endpoints
+type Environment struct {
+	Name string
+	// HTTPURL is the base URL of HTTP requests, without BasePath
+	HTTPURL string
+	// BasePath is the path of HTTPURL moved into its own variable with
+	// split_base_path, or empty
+	BasePath string
+	// GRPCURL is the host:port of gRPC requests
+	GRPCURL string
+}
+
+// GRPCTLS reports whether the gRPC endpoint of the environment is served over
+// TLS, either on port 443 or next to an HTTPS base URL
+func (env Environment) GRPCTLS() bool {
+	return strings.HasSuffix(env.GRPCURL, ":443") || strings.HasPrefix(env.HTTPURL, "https://")
+}
+
+// Config is the base URLs of the environments, as given by the local_url,
+// dev_url, stg_url and prd_url options and their grpc_ counterparts. Empty
+// URLs are left out, and an empty gRPC URL is derived from the HTTP URL.
+type Config struct {
+	LocalURL, DevURL, StgURL, PrdURL                 string
+	GRPCLocalURL, GRPCDevURL, GRPCStgURL, GRPCPrdURL string
+}
+
+// Build returns the environments of a collection. Local is included when its
+// URL is set or no other environment is, at http://localhost:8080 and
+// localhost:50051 by default.
+func Build(cfg Config) []Environment {
+	var environments []Environment
+	if cfg.LocalURL != "" || (cfg.DevURL == "" && cfg.StgURL == "" && cfg.PrdURL == "") {
+		env := Environment{Name: "Local", HTTPURL: "http://localhost:8080", GRPCURL: "localhost:50051"}
+		if cfg.LocalURL != "" {
+			env.HTTPURL, env.GRPCURL = cfg.LocalURL, GRPCHost(cfg.LocalURL)
+		}
+		if cfg.GRPCLocalURL != "" {
+			env.GRPCURL = cfg.GRPCLocalURL
+		}
+		environments = append(environments, env)
+	}
+	for _, remote := range []struct{ name, httpURL, grpcURL string }{
+		{"Development", cfg.DevURL, cfg.GRPCDevURL},
+		{"Staging", cfg.StgURL, cfg.GRPCStgURL},
+		{"Production", cfg.PrdURL, cfg.GRPCPrdURL},
+	} {
+		if remote.httpURL == "" {
+			continue
+		}
+		env := Environment{Name: remote.name, HTTPURL: remote.httpURL, GRPCURL: GRPCHost(remote.httpURL)}
+		// Override with explicit gRPC URL if provided
+		if remote.grpcURL != "" {
+			env.GRPCURL = remote.grpcURL
+		}
+		environments = append(environments, env)
+	}
+	return environments
+}
+
+// GRPCHost converts an HTTP(S) URL to a gRPC host:port
+// Examples:
+//
+//	https://api.dev.example.com/service -> api.dev.example.com:443
+//	http://localhost:8080 -> localhost:8080
+func GRPCHost(httpURL string) string {
+	// Remove protocol
+	url := strings.TrimPrefix(httpURL, "https://")
+	url = strings.TrimPrefix(url, "http://")
+
+	// Remove path if present
+	if idx := strings.Index(url, "/"); idx != -1 {
+		url = url[:idx]
+	}
+
+	// Add default port if not present
+	if !strings.Contains(url, ":") {
+		if strings.HasPrefix(httpURL, "https://") {
+			url += ":443"
+		} else {
+			url += ":80"
+		}
+	}
+
+	return url
+}
+
+// SplitURLPath splits an HTTP(S) URL into its origin and path
+// Examples:
+//
+//	https://api.dev.example.com/service -> https://api.dev.example.com, /service
+//	http://localhost:8080 -> http://localhost:8080, ""
+func SplitURLPath(httpURL string) (origin, path string) {
+	hostStart := 0
+	if idx := strings.Index(httpURL, "://"); idx != -1 {
+		hostStart = idx + len("://")
+	}
+
+	if idx := strings.Index(httpURL[hostStart:], "/"); idx != -1 {
+		origin = httpURL[:hostStart+idx]
+		path = strings.TrimSuffix(httpURL[hostStart+idx:], "/")
+		return origin, path
+	}
+	return httpURL, ""
+}
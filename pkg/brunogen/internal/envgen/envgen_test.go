@@ -0,0 +1,83 @@
+package envgen
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want []Environment
+	}{
+		{
+			name: "defaults",
+			want: []Environment{{Name: "Local", HTTPURL: "http://localhost:8080", GRPCURL: "localhost:50051"}},
+		},
+		{
+			name: "remote only",
+			cfg:  Config{DevURL: "https://api.dev.example.com/service", PrdURL: "https://api.example.com", GRPCPrdURL: "grpc.example.com:443"},
+			want: []Environment{
+				{Name: "Development", HTTPURL: "https://api.dev.example.com/service", GRPCURL: "api.dev.example.com:443"},
+				{Name: "Production", HTTPURL: "https://api.example.com", GRPCURL: "grpc.example.com:443"},
+			},
+		},
+		{
+			name: "local and staging",
+			cfg:  Config{LocalURL: "http://localhost:3000", GRPCLocalURL: "localhost:9090", StgURL: "http://staging.internal"},
+			want: []Environment{
+				{Name: "Local", HTTPURL: "http://localhost:3000", GRPCURL: "localhost:9090"},
+				{Name: "Staging", HTTPURL: "http://staging.internal", GRPCURL: "staging.internal:80"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Build(tt.cfg); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Build() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGRPCTLS(t *testing.T) {
+	tests := []struct {
+		env  Environment
+		want bool
+	}{
+		{env: Environment{HTTPURL: "http://localhost:8080", GRPCURL: "localhost:50051"}, want: false},
+		{env: Environment{HTTPURL: "https://api.example.com", GRPCURL: "api.example.com:8443"}, want: true},
+		{env: Environment{HTTPURL: "http://localhost:8080", GRPCURL: "grpc.example.com:443"}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env.GRPCURL, func(t *testing.T) {
+			if got := tt.env.GRPCTLS(); got != tt.want {
+				t.Errorf("GRPCTLS() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestURLs(t *testing.T) {
+	tests := []struct {
+		url          string
+		host         string
+		origin, path string
+	}{
+		{url: "http://localhost:8080", host: "localhost:8080", origin: "http://localhost:8080"},
+		{url: "https://api.dev.example.com/service", host: "api.dev.example.com:443", origin: "https://api.dev.example.com", path: "/service"},
+		{url: "https://api.example.com/v1/", host: "api.example.com:443", origin: "https://api.example.com", path: "/v1"},
+		{url: "http://example.com", host: "example.com:80", origin: "http://example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := GRPCHost(tt.url); got != tt.host {
+				t.Errorf("GRPCHost() = %q, want %q", got, tt.host)
+			}
+			if origin, path := SplitURLPath(tt.url); origin != tt.origin || path != tt.path {
+				t.Errorf("SplitURLPath() = %q, %q, want %q, %q", origin, path, tt.origin, tt.path)
+			}
+		})
+	}
+}
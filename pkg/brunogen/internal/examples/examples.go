@@ -0,0 +1,226 @@
+// Package examples builds example values of proto messages and fields in
+// their proto3 JSON form, for request bodies, query parameters and docs.
+package examples
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Past the depth limit only required fields are written, since proto2
+// messages missing them are rejected; maxRequiredDepth stops required cycles
+const maxRequiredDepth = 8
+
+// TruncatedKey is the key of the field marking where an example was cut
+// short by the size limit
+const TruncatedKey = "_truncated"
+
+// Limits bounds the examples of deeply recursive or very wide messages
+type Limits struct {
+	// Depth is the nesting depth down to which every field is written
+	Depth int
+	// Size is the length in bytes past which no more fields are written, or 0
+	Size int
+}
+
+// DefaultLimits are the limits of the max_example_depth and max_example_size
+// options by default
+var DefaultLimits = Limits{Depth: 3, Size: 64 << 10}
+
+// writer builds an example within its limits
+type writer struct {
+	strings.Builder
+	limits    Limits
+	truncated bool
+}
+
+// JSON returns example JSON for a proto message, such as a request body, with
+// every field set to an example of its type. An example reaching the size
+// limit gets a TruncatedKey field where it was cut, and its remaining fields
+// are left out.
+func JSON(msg *protogen.Message, limits Limits) string {
+	w := &writer{limits: limits}
+	writeExampleJSON(w, msg, 0)
+	return w.String()
+}
+
+// writeExampleJSON writes example JSON for a proto message. The builder is
+// shared down the recursion, so messages with hundreds of fields are not
+// assembled from a string per field.
+func writeExampleJSON(b *writer, msg *protogen.Message, indent int) {
+	// Prevent infinite recursion by limiting depth
+	fields := msg.Fields
+	if indent >= b.limits.Depth {
+		fields = requiredFields(msg)
+	}
+	if indent >= max(maxRequiredDepth, b.limits.Depth) || (indent >= b.limits.Depth && len(fields) == 0) {
+		b.WriteString("{}")
+		return
+	}
+
+	b.WriteString("{")
+	for i, field := range fields {
+		// Stop at the size limit, marking the cut in the innermost message
+		if b.limits.Size > 0 && b.Len() >= b.limits.Size {
+			if !b.truncated {
+				b.truncated = true
+				if i > 0 {
+					b.WriteString(",")
+				}
+				b.WriteString("\n")
+				writeIndent(b, indent+1)
+				fmt.Fprintf(b, "%q: %q", TruncatedKey, fmt.Sprintf("example over %d bytes, see max_example_size", b.limits.Size))
+			}
+			break
+		}
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n")
+		writeIndent(b, indent+1)
+		b.WriteString(strconv.Quote(field.Desc.JSONName()))
+		b.WriteString(": ")
+
+		// Generate value based on field type
+		if field.Desc.IsList() {
+			// Handle repeated fields (arrays)
+			b.WriteString("[")
+			writeFieldValue(b, field, indent+1)
+			b.WriteString("]")
+		} else {
+			writeFieldValue(b, field, indent+1)
+		}
+	}
+	b.WriteString("\n")
+	writeIndent(b, indent)
+	b.WriteString("}")
+}
+
+// requiredFields returns the fields of a message that must be set, like proto2
+// required fields
+func requiredFields(msg *protogen.Message) []*protogen.Field {
+	var fields []*protogen.Field
+	for _, field := range msg.Fields {
+		if field.Desc.Cardinality() == protoreflect.Required {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// writeIndent writes the indentation of a JSON nesting level
+func writeIndent(b *writer, indent int) {
+	for range indent {
+		b.WriteString("  ")
+	}
+}
+
+// FieldValue returns an example value for a field as JSON, such as a query
+// parameter
+func FieldValue(field *protogen.Field, limits Limits) string {
+	w := &writer{limits: limits}
+	writeFieldValue(w, field, 0)
+	return w.String()
+}
+
+// writeFieldValue writes an example value for a field
+func writeFieldValue(b *writer, field *protogen.Field, indent int) {
+	// Explicit defaults, like proto2 [default = ...], make better examples
+	if field.Desc.HasDefault() {
+		b.WriteString(defaultValueJSON(field.Desc))
+		return
+	}
+
+	kind := field.Desc.Kind()
+
+	switch kind {
+	case protoreflect.StringKind:
+		// Use field name as example value
+		b.WriteString(strconv.Quote("example_" + field.Desc.JSONName()))
+	case protoreflect.Int32Kind, protoreflect.Int64Kind,
+		protoreflect.Uint32Kind, protoreflect.Uint64Kind,
+		protoreflect.Sint32Kind, protoreflect.Sint64Kind,
+		protoreflect.Fixed32Kind, protoreflect.Fixed64Kind,
+		protoreflect.Sfixed32Kind, protoreflect.Sfixed64Kind:
+		b.WriteString("0")
+	case protoreflect.BoolKind:
+		b.WriteString("false")
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		b.WriteString("0.0")
+	case protoreflect.BytesKind:
+		b.WriteString(`"base64_encoded_data"`)
+	case protoreflect.EnumKind:
+		// Get first enum value
+		enum := field.Enum
+		if enum != nil && len(enum.Values) > 0 {
+			b.WriteString(strconv.Quote(string(enum.Values[0].Desc.Name())))
+		} else {
+			b.WriteString(`"ENUM_VALUE"`)
+		}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		// Delimited (group) encoding does not change the JSON form of a message
+		if field.Message == nil {
+			b.WriteString("{}")
+			return
+		}
+		// Check for well-known types that have special JSON serialization
+		switch field.Message.Desc.FullName() {
+		case "google.protobuf.Timestamp":
+			b.WriteString(`"2024-01-01T00:00:00Z"`)
+		case "google.protobuf.Duration":
+			b.WriteString(`"1.5s"`)
+		case "google.protobuf.Any":
+			b.WriteString(`{"@type": "type.googleapis.com/example.Type", "value": "..."}`)
+		case "google.protobuf.FieldMask":
+			b.WriteString(`"field1,field2.subfield"`)
+		case "google.protobuf.Struct":
+			b.WriteString(`{}`)
+		case "google.protobuf.Value":
+			b.WriteString(`null`)
+		case "google.protobuf.ListValue":
+			b.WriteString(`[]`)
+		case "google.protobuf.Empty":
+			b.WriteString(`{}`)
+		default:
+			// For other message types, recursively generate JSON
+			writeExampleJSON(b, field.Message, indent)
+		}
+	default:
+		b.WriteString(`"unknown"`)
+	}
+}
+
+// defaultValueJSON returns the explicit default value of a field as JSON
+func defaultValueJSON(field protoreflect.FieldDescriptor) string {
+	value := field.Default()
+	switch field.Kind() {
+	case protoreflect.StringKind:
+		return strconv.Quote(value.String())
+	case protoreflect.BytesKind:
+		return strconv.Quote(base64.StdEncoding.EncodeToString(value.Bytes()))
+	case protoreflect.EnumKind:
+		return strconv.Quote(string(field.DefaultEnumValue().Name()))
+	case protoreflect.BoolKind:
+		return strconv.FormatBool(value.Bool())
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		f := value.Float()
+		switch {
+		case math.IsNaN(f):
+			return `"NaN"`
+		case math.IsInf(f, 1):
+			return `"Infinity"`
+		case math.IsInf(f, -1):
+			return `"-Infinity"`
+		}
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	default:
+		// Integers
+		return value.String()
+	}
+}
@@ -0,0 +1,148 @@
+package examples
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// testMessage returns the Node message of a proto2 file, with fields of most
+// kinds, explicit defaults, a required field and a recursive child
+func testMessage(t *testing.T) *protogen.Message {
+	t.Helper()
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Type:     typ.Enum(),
+			Label:    label.Enum(),
+		}
+	}
+	optional, repeated, required := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL, descriptorpb.FieldDescriptorProto_LABEL_REPEATED, descriptorpb.FieldDescriptorProto_LABEL_REQUIRED
+	withType := func(f *descriptorpb.FieldDescriptorProto, typeName string) *descriptorpb.FieldDescriptorProto {
+		f.TypeName = proto.String(typeName)
+		return f
+	}
+	withDefault := func(f *descriptorpb.FieldDescriptorProto, value string) *descriptorpb.FieldDescriptorProto {
+		f.DefaultValue = proto.String(value)
+		return f
+	}
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("test/v1/node.proto"),
+		Package:    proto.String("test.v1"),
+		Syntax:     proto.String("proto2"),
+		Dependency: []string{"google/protobuf/timestamp.proto"},
+		Options:    &descriptorpb.FileOptions{GoPackage: proto.String("example.com/test/v1;testv1")},
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Color"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("COLOR_UNSPECIFIED"), Number: proto.Int32(0)},
+				{Name: proto.String("BLUE"), Number: proto.Int32(1)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Node"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, required),
+				field("ids", 2, descriptorpb.FieldDescriptorProto_TYPE_INT64, repeated),
+				withDefault(field("on", 3, descriptorpb.FieldDescriptorProto_TYPE_BOOL, optional), "true"),
+				withType(field("color", 4, descriptorpb.FieldDescriptorProto_TYPE_ENUM, optional), ".test.v1.Color"),
+				withDefault(withType(field("shade", 5, descriptorpb.FieldDescriptorProto_TYPE_ENUM, optional), ".test.v1.Color"), "BLUE"),
+				withDefault(field("data", 6, descriptorpb.FieldDescriptorProto_TYPE_BYTES, optional), "hi"),
+				withDefault(field("ratio", 7, descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, optional), "inf"),
+				withType(field("at", 8, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, optional), ".google.protobuf.Timestamp"),
+				withType(field("child", 9, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, optional), ".test.v1.Node"),
+			},
+		}},
+	}
+	gen, err := protogen.Options{}.New(&pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{file.GetName()},
+		ProtoFile:      []*descriptorpb.FileDescriptorProto{protodesc.ToFileDescriptorProto(timestamppb.File_google_protobuf_timestamp_proto), file},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gen.FilesByPath[file.GetName()].Messages[0]
+}
+
+func TestJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits Limits
+		want   string
+	}{
+		{
+			name:   "past the depth limit only required fields are set",
+			limits: Limits{Depth: 1},
+			want: `{
+  "id": "example_id",
+  "ids": [0],
+  "on": true,
+  "color": "COLOR_UNSPECIFIED",
+  "shade": "BLUE",
+  "data": "aGk=",
+  "ratio": "Infinity",
+  "at": "2024-01-01T00:00:00Z",
+  "child": {
+    "id": "example_id"
+  }
+}`,
+		},
+		{
+			name:   "no depth",
+			limits: Limits{},
+			want:   "{\n  \"id\": \"example_id\"\n}",
+		},
+		{
+			name:   "cut at the size limit",
+			limits: Limits{Depth: 2, Size: 40},
+			want: `{
+  "id": "example_id",
+  "ids": [0],
+  "on": true,
+  "_truncated": "example over 40 bytes, see max_example_size"
+}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := JSON(testMessage(t), tt.limits); got != tt.want {
+				t.Errorf("JSON() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldValue(t *testing.T) {
+	tests := []struct {
+		field string
+		want  string
+	}{
+		{field: "id", want: `"example_id"`},
+		{field: "ids", want: "0"},
+		{field: "on", want: "true"},
+		{field: "color", want: `"COLOR_UNSPECIFIED"`},
+		{field: "shade", want: `"BLUE"`},
+		{field: "data", want: `"aGk="`},
+		{field: "ratio", want: `"Infinity"`},
+		{field: "at", want: `"2024-01-01T00:00:00Z"`},
+		{field: "child", want: "{\n  \"id\": \"example_id\"\n}"},
+	}
+	fields := make(map[string]*protogen.Field)
+	for _, field := range testMessage(t).Fields {
+		fields[string(field.Desc.Name())] = field
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			if got := FieldValue(fields[tt.field], Limits{}); got != tt.want {
+				t.Errorf("FieldValue() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
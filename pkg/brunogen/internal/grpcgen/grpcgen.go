@@ -0,0 +1,48 @@
+// Package grpcgen describes the RPCs of services for the gRPC requests of a
+// collection and the grpcurl commands of its scripts.
+package grpcgen
+
+import (
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// RPCName returns the full name of a method as package.Service/Method, the
+// form method filters match
+func RPCName(method *protogen.Method) string {
+	return string(method.Parent.Desc.FullName()) + "/" + string(method.Desc.Name())
+}
+
+// MethodType returns the Bruno method type for an RPC based on its streaming mode
+func MethodType(method *protogen.Method) string {
+	switch {
+	case method.Desc.IsStreamingClient() && method.Desc.IsStreamingServer():
+		return "bidi-streaming"
+	case method.Desc.IsStreamingClient():
+		return "client-streaming"
+	case method.Desc.IsStreamingServer():
+		return "server-streaming"
+	default:
+		return "unary"
+	}
+}
+
+// TLS is how a gRPC endpoint is reached
+type TLS struct {
+	// Enabled is whether the endpoint is served over TLS
+	Enabled bool
+	// CertFile and KeyFile are the client certificate of mutual TLS, if any
+	CertFile string
+	KeyFile  string
+}
+
+// GrpcurlFlags returns the grpcurl flags for an endpoint: -plaintext only when
+// it is served without TLS, and the client certificate with mutual TLS
+func GrpcurlFlags(tls TLS) string {
+	if tls.CertFile != "" {
+		return "-cert " + tls.CertFile + " -key " + tls.KeyFile
+	}
+	if tls.Enabled {
+		return ""
+	}
+	return "-plaintext"
+}
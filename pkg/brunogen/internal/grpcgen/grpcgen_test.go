@@ -0,0 +1,78 @@
+package grpcgen
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+func TestMethods(t *testing.T) {
+	method := func(name string, clientStreaming, serverStreaming bool) *descriptorpb.MethodDescriptorProto {
+		return &descriptorpb.MethodDescriptorProto{
+			Name:            proto.String(name),
+			InputType:       proto.String(".example.v1.Empty"),
+			OutputType:      proto.String(".example.v1.Empty"),
+			ClientStreaming: proto.Bool(clientStreaming),
+			ServerStreaming: proto.Bool(serverStreaming),
+		}
+	}
+	file := &descriptorpb.FileDescriptorProto{
+		Name:        proto.String("example/v1/chat.proto"),
+		Package:     proto.String("example.v1"),
+		Syntax:      proto.String("proto3"),
+		Options:     &descriptorpb.FileOptions{GoPackage: proto.String("example.com/example/v1;examplev1")},
+		MessageType: []*descriptorpb.DescriptorProto{{Name: proto.String("Empty")}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{Name: proto.String("ChatService"), Method: []*descriptorpb.MethodDescriptorProto{
+			method("Get", false, false),
+			method("Upload", true, false),
+			method("Watch", false, true),
+			method("Chat", true, true),
+		}}},
+	}
+	gen, err := protogen.Options{}.New(&pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{file.GetName()},
+		ProtoFile:      []*descriptorpb.FileDescriptorProto{file},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"example.v1.ChatService/Get":    "unary",
+		"example.v1.ChatService/Upload": "client-streaming",
+		"example.v1.ChatService/Watch":  "server-streaming",
+		"example.v1.ChatService/Chat":   "bidi-streaming",
+	}
+	for _, m := range gen.Files[0].Services[0].Methods {
+		name := RPCName(m)
+		if _, ok := want[name]; !ok {
+			t.Errorf("RPCName() = %q", name)
+			continue
+		}
+		if got := MethodType(m); got != want[name] {
+			t.Errorf("MethodType(%s) = %q, want %q", name, got, want[name])
+		}
+	}
+}
+
+func TestGrpcurlFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		tls  TLS
+		want string
+	}{
+		{name: "plaintext", want: "-plaintext"},
+		{name: "tls", tls: TLS{Enabled: true}},
+		{name: "mtls", tls: TLS{Enabled: true, CertFile: "certs/client.crt", KeyFile: "certs/client.key"}, want: "-cert certs/client.crt -key certs/client.key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GrpcurlFlags(tt.tls); got != tt.want {
+				t.Errorf("GrpcurlFlags() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
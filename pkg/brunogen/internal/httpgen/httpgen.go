@@ -0,0 +1,96 @@
+// Package httpgen reads the google.api.http rules of methods and turns their
+// paths into request URLs, for the HTTP requests of a collection.
+package httpgen
+
+import (
+	"fmt"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Config is how rules are read
+type Config struct {
+	// Placeholders gives invalid rules a placeholder HTTP method and path, as
+	// with invalid_http_rules=placeholder, instead of leaving them empty
+	Placeholders bool
+}
+
+// MethodRule returns the google.api.http rule of a method with its HTTP
+// method and path, and what is wrong with the rule, if anything. The HTTP
+// method and path of an invalid rule are placeholders with
+// Config.Placeholders, and empty otherwise. ok is false for methods without a
+// rule.
+func MethodRule(method *protogen.Method, cfg Config) (rule *annotations.HttpRule, httpMethod string, path string, problem string, ok bool) {
+	opts := method.Desc.Options()
+	if !proto.HasExtension(opts, annotations.E_Http) {
+		return nil, "", "", "", false
+	}
+	rule = proto.GetExtension(opts, annotations.E_Http).(*annotations.HttpRule)
+	httpMethod, path = Extract(rule)
+	switch {
+	case httpMethod == "" || path == "":
+		problem = Unsupported(rule)
+	case rule.Body != "" && rule.Body != "*" && method.Input.Desc.Fields().ByName(protoreflect.Name(rule.Body)) == nil:
+		problem = fmt.Sprintf("body field %q is not a field of %s", rule.Body, method.Input.Desc.FullName())
+	}
+	if problem == "" || !cfg.Placeholders {
+		return rule, httpMethod, path, problem, true
+	}
+
+	// Placeholders keep what the rule gets right
+	if httpMethod == "" {
+		httpMethod = "get"
+		if rule.Body != "" {
+			httpMethod = "post"
+		}
+	}
+	if path == "" {
+		if custom, isCustom := rule.Pattern.(*annotations.HttpRule_Custom); isCustom && custom.Custom.GetPath() != "" {
+			path = custom.Custom.GetPath()
+		} else {
+			path = "/" + string(method.Parent.Desc.FullName()) + "/" + string(method.Desc.Name())
+		}
+	}
+	return rule, httpMethod, path, problem, true
+}
+
+// Extract returns the lower-case HTTP method and the path of a rule, or empty
+// strings for custom methods and rules without a pattern
+func Extract(rule *annotations.HttpRule) (method, path string) {
+	switch pattern := rule.Pattern.(type) {
+	case *annotations.HttpRule_Get:
+		return "get", pattern.Get
+	case *annotations.HttpRule_Post:
+		return "post", pattern.Post
+	case *annotations.HttpRule_Put:
+		return "put", pattern.Put
+	case *annotations.HttpRule_Delete:
+		return "delete", pattern.Delete
+	case *annotations.HttpRule_Patch:
+		return "patch", pattern.Patch
+	}
+	return "", ""
+}
+
+// Unsupported describes why no request is generated for an HTTP rule
+func Unsupported(rule *annotations.HttpRule) string {
+	switch pattern := rule.Pattern.(type) {
+	case *annotations.HttpRule_Custom:
+		return fmt.Sprintf("unsupported HTTP method %q in google.api.http", pattern.Custom.GetKind())
+	case nil:
+		return "no HTTP method in google.api.http"
+	}
+	return "empty path in google.api.http"
+}
+
+// InvalidRuleNotice returns the docs warning of a placeholder request
+// generated for an invalid google.api.http rule
+func InvalidRuleNotice(problem string) []string {
+	if problem == "" {
+		return nil
+	}
+	return []string{"> **Warning:** the google.api.http rule of this method is invalid: " + problem + ". This request is a placeholder; fix the rule and regenerate."}
+}
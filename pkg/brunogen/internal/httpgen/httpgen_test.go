@@ -0,0 +1,132 @@
+package httpgen
+
+import (
+	"reflect"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// testMethod returns the method UpdateUser of example.v1.UserService with an
+// HTTP rule, if any
+func testMethod(t *testing.T, rule *annotations.HttpRule) *protogen.Method {
+	t.Helper()
+	method := &descriptorpb.MethodDescriptorProto{
+		Name:       proto.String("UpdateUser"),
+		InputType:  proto.String(".example.v1.User"),
+		OutputType: proto.String(".example.v1.User"),
+	}
+	if rule != nil {
+		method.Options = &descriptorpb.MethodOptions{}
+		proto.SetExtension(method.Options, annotations.E_Http, rule)
+	}
+	file := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("example/v1/user.proto"),
+		Package: proto.String("example.v1"),
+		Syntax:  proto.String("proto3"),
+		Options: &descriptorpb.FileOptions{GoPackage: proto.String("example.com/example/v1;examplev1")},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name:  proto.String("User"),
+			Field: []*descriptorpb.FieldDescriptorProto{{Name: proto.String("name"), Number: proto.Int32(1), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), JsonName: proto.String("name")}},
+		}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{Name: proto.String("UserService"), Method: []*descriptorpb.MethodDescriptorProto{method}}},
+	}
+	gen, err := protogen.Options{}.New(&pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{file.GetName()},
+		ProtoFile:      []*descriptorpb.FileDescriptorProto{file},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gen.Files[0].Services[0].Methods[0]
+}
+
+func TestMethodRule(t *testing.T) {
+	tests := []struct {
+		name         string
+		rule         *annotations.HttpRule
+		placeholders bool
+		verb, path   string
+		problem      string
+		ok           bool
+	}{
+		{name: "no rule"},
+		{
+			name: "patch",
+			rule: &annotations.HttpRule{Pattern: &annotations.HttpRule_Patch{Patch: "/v1/{name=users/*}"}, Body: "*"},
+			verb: "patch", path: "/v1/{name=users/*}", ok: true,
+		},
+		{
+			name: "unknown body field",
+			rule: &annotations.HttpRule{Pattern: &annotations.HttpRule_Post{Post: "/v1/users"}, Body: "user"},
+			verb: "post", path: "/v1/users", problem: `body field "user" is not a field of example.v1.User`, ok: true,
+		},
+		{
+			name:    "custom method",
+			rule:    &annotations.HttpRule{Pattern: &annotations.HttpRule_Custom{Custom: &annotations.CustomHttpPattern{Kind: "HEAD", Path: "/v1/users"}}},
+			problem: `unsupported HTTP method "HEAD" in google.api.http`, ok: true,
+		},
+		{
+			name:         "custom method placeholder",
+			rule:         &annotations.HttpRule{Pattern: &annotations.HttpRule_Custom{Custom: &annotations.CustomHttpPattern{Kind: "HEAD", Path: "/v1/users"}}},
+			placeholders: true,
+			verb:         "get", path: "/v1/users", problem: `unsupported HTTP method "HEAD" in google.api.http`, ok: true,
+		},
+		{
+			name:         "no pattern placeholder",
+			rule:         &annotations.HttpRule{Body: "*"},
+			placeholders: true,
+			verb:         "post", path: "/example.v1.UserService/UpdateUser", problem: "no HTTP method in google.api.http", ok: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, verb, path, problem, ok := MethodRule(testMethod(t, tt.rule), Config{Placeholders: tt.placeholders})
+			if verb != tt.verb || path != tt.path || problem != tt.problem || ok != tt.ok {
+				t.Errorf("MethodRule() = %q, %q, %q, %v, want %q, %q, %q, %v", verb, path, problem, ok, tt.verb, tt.path, tt.problem, tt.ok)
+			}
+		})
+	}
+}
+
+func TestInvalidRuleNotice(t *testing.T) {
+	if got := InvalidRuleNotice(""); got != nil {
+		t.Errorf("InvalidRuleNotice(\"\") = %q, want nil", got)
+	}
+	want := []string{"> **Warning:** the google.api.http rule of this method is invalid: empty path in google.api.http. This request is a placeholder; fix the rule and regenerate."}
+	if got := InvalidRuleNotice("empty path in google.api.http"); !reflect.DeepEqual(got, want) {
+		t.Errorf("InvalidRuleNotice() = %q, want %q", got, want)
+	}
+}
+
+func TestPathVariables(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    string
+		vars    [][2]string
+		example string
+	}{
+		{path: "/v1/users", want: "/v1/users", example: "/v1/users"},
+		{path: "/v1/users/{user_id}", want: "/v1/users/{{user_id}}", vars: [][2]string{{"user_id", "example_user_id"}}, example: "/v1/users/example_user_id"},
+		{path: "/v1/{name=users/*}", want: "/v1/{{name}}", vars: [][2]string{{"name", "users/example_name"}}, example: "/v1/users/example_name"},
+		{path: "/v1/{book.name=shelves/*/books/**}", want: "/v1/{{book_name}}", vars: [][2]string{{"book_name", "shelves/example_name/books/example_name"}}, example: "/v1/shelves/example_name/books/example_name"},
+		{path: "/v1/users/{{user_id}}/posts/{post_id}", want: "/v1/users/{{user_id}}/posts/{{post_id}}", vars: [][2]string{{"post_id", "example_post_id"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, vars := PathVariables(tt.path)
+			if got != tt.want || !reflect.DeepEqual(vars, tt.vars) {
+				t.Errorf("PathVariables() = %q, %q, want %q, %q", got, vars, tt.want, tt.vars)
+			}
+			if tt.example != "" {
+				if got := ExamplePath(tt.path); got != tt.example {
+					t.Errorf("ExamplePath() = %q, want %q", got, tt.example)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package httpgen
+
+import (
+	"strings"
+)
+
+// PathVariables replaces the path parameters of a request path with request
+// variables, e.g. {user_id} -> {{user_id}} and {name=users/*} -> {{name}}, and
+// returns the example value of each variable. Variables already referenced,
+// such as chained resource IDs, are left alone.
+func PathVariables(path string) (string, [][2]string) {
+	var b strings.Builder
+	var vars [][2]string
+	for {
+		start := strings.Index(path, "{")
+		if start == -1 {
+			b.WriteString(path)
+			return b.String(), vars
+		}
+		if strings.HasPrefix(path[start:], "{{") {
+			end := strings.Index(path[start:], "}}") + start + 2
+			b.WriteString(path[:end])
+			path = path[end:]
+			continue
+		}
+		end := strings.Index(path[start:], "}") + start
+		b.WriteString(path[:start])
+
+		param, _, _ := strings.Cut(path[start+1:end], "=")
+		name := strings.ReplaceAll(param, ".", "_")
+		vars = append(vars, [2]string{name, ExamplePath(path[start : end+1])})
+		b.WriteString("{{" + name + "}}")
+		path = path[end+1:]
+	}
+}
+
+// ExamplePath fills the path parameters of an HTTP rule path with example
+// values: {user_id} -> example_user_id, {name=users/*} -> users/example_name
+func ExamplePath(path string) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(path, "{")
+		end := strings.Index(path, "}")
+		if start == -1 || end < start {
+			b.WriteString(path)
+			return b.String()
+		}
+		b.WriteString(path[:start])
+
+		param, pattern, ok := strings.Cut(path[start+1:end], "=")
+		name := param[strings.LastIndex(param, ".")+1:]
+		if ok {
+			b.WriteString(strings.ReplaceAll(strings.ReplaceAll(pattern, "**", "*"), "*", "example_"+name))
+		} else {
+			b.WriteString("example_" + name)
+		}
+		path = path[end+1:]
+	}
+}
@@ -0,0 +1,80 @@
+// Package naming derives the names of generated requests, folders and files
+// from proto names: word splitting, case conventions and sanitizing.
+package naming
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SplitWords splits a name into words at case changes and separators, keeping
+// acronyms together ("HTTPServer" -> "HTTP", "Server")
+func SplitWords(name string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case unicode.IsUpper(r):
+			// Start a new word on lower->upper transitions and before the last
+			// upper-case letter of an acronym
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) && len(word) > 0 {
+				words = append(words, string(word))
+				word = nil
+			}
+			word = append(word, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word = append(word, r)
+		default:
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = nil
+			}
+		}
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
+
+// SnakeCase converts names like "ApiKeyAuth" or "api-key" to "api_key_auth" / "api_key"
+func SnakeCase(name string) string {
+	return strings.ToLower(strings.Join(SplitWords(name), "_"))
+}
+
+// KebabCase converts names like "UserService" to "user-service"
+func KebabCase(name string) string {
+	return strings.ToLower(strings.Join(SplitWords(name), "-"))
+}
+
+// CamelCase converts names like "UserService" or "get_user" to "userService" / "getUser"
+func CamelCase(name string) string {
+	words := SplitWords(name)
+	for i, word := range words {
+		if i == 0 {
+			words[i] = strings.ToLower(word)
+		} else {
+			words[i] = strings.ToUpper(word[:1]) + strings.ToLower(word[1:])
+		}
+	}
+	return strings.Join(words, "")
+}
+
+// Display splits a name like "UserService" into "User Service"
+func Display(name string) string {
+	return strings.Join(SplitWords(name), " ")
+}
+
+// PackageTitle converts "example.v1" to "Example V1"
+func PackageTitle(pkg string) string {
+	parts := strings.Split(pkg, ".")
+	for i, part := range parts {
+		// Capitalize first letter
+		if len(part) > 0 {
+			parts[i] = strings.ToUpper(part[:1]) + part[1:]
+		}
+	}
+	return strings.Join(parts, " ")
+}
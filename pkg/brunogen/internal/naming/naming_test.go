@@ -0,0 +1,109 @@
+package naming
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCases(t *testing.T) {
+	tests := []struct {
+		name    string
+		words   []string
+		snake   string
+		kebab   string
+		camel   string
+		display string
+	}{
+		{name: "UserService", words: []string{"User", "Service"}, snake: "user_service", kebab: "user-service", camel: "userService", display: "User Service"},
+		{name: "HTTPServer", words: []string{"HTTP", "Server"}, snake: "http_server", kebab: "http-server", camel: "httpServer", display: "HTTP Server"},
+		{name: "getUserV2", words: []string{"get", "User", "V2"}, snake: "get_user_v2", kebab: "get-user-v2", camel: "getUserV2", display: "get User V2"},
+		{name: "api-key", words: []string{"api", "key"}, snake: "api_key", kebab: "api-key", camel: "apiKey", display: "api key"},
+		{name: "user_id", words: []string{"user", "id"}, snake: "user_id", kebab: "user-id", camel: "userId", display: "user id"},
+		{name: "ÉtéService", words: []string{"Été", "Service"}, snake: "été_service", kebab: "été-service", camel: "étéService", display: "Été Service"},
+		{name: "v1", words: []string{"v1"}, snake: "v1", kebab: "v1", camel: "v1", display: "v1"},
+		{name: "", words: nil, snake: "", kebab: "", camel: "", display: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitWords(tt.name); !reflect.DeepEqual(got, tt.words) {
+				t.Errorf("SplitWords() = %q, want %q", got, tt.words)
+			}
+			if got := SnakeCase(tt.name); got != tt.snake {
+				t.Errorf("SnakeCase() = %q, want %q", got, tt.snake)
+			}
+			if got := KebabCase(tt.name); got != tt.kebab {
+				t.Errorf("KebabCase() = %q, want %q", got, tt.kebab)
+			}
+			if got := CamelCase(tt.name); got != tt.camel {
+				t.Errorf("CamelCase() = %q, want %q", got, tt.camel)
+			}
+			if got := Display(tt.name); got != tt.display {
+				t.Errorf("Display() = %q, want %q", got, tt.display)
+			}
+		})
+	}
+}
+
+func TestPackageTitle(t *testing.T) {
+	tests := []struct {
+		pkg  string
+		want string
+	}{
+		{pkg: "example.v1", want: "Example V1"},
+		{pkg: "acme.billing.v1beta1", want: "Acme Billing V1beta1"},
+		{pkg: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pkg, func(t *testing.T) {
+			if got := PackageTitle(tt.pkg); got != tt.want {
+				t.Errorf("PackageTitle(%q) = %q, want %q", tt.pkg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "plain", in: "Get User", want: "Get User"},
+		{name: "whitespace runs", in: "Get\nUser\t  by ID ", want: "Get User by ID"},
+		{name: "control characters", in: "a\x00b", want: "a b"},
+		{name: "long names are truncated", in: strings.Repeat("b", 150), want: strings.Repeat("b", 100-len(nameHash(strings.Repeat("b", 150)))-1) + "-" + nameHash(strings.Repeat("b", 150))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sanitize(tt.in); got != tt.want {
+				t.Errorf("Sanitize(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeFile(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "plain", in: "UserService", want: "UserService"},
+		{name: "allowed punctuation", in: "Get User (v1)_x-y.bru", want: "Get User (v1)_x-y.bru"},
+		{name: "separators become a dash", in: "User/Service:v1", want: "User-Service-v1"},
+		{name: "runs become one dash", in: "a/*?b", want: "a-b"},
+		{name: "leading dashes and trailing dots", in: "-flag. ", want: "flag"},
+		{name: "windows reserved name", in: "CON", want: "_CON"},
+		{name: "windows reserved name with extension", in: "con.txt", want: "_con.txt"},
+		{name: "no letter or digit", in: "***", want: nameHash("***")},
+		{name: "long names are truncated", in: strings.Repeat("a", 120), want: strings.Repeat("a", 100-len(nameHash(strings.Repeat("a", 120)))-1) + "-" + nameHash(strings.Repeat("a", 120))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeFile(tt.in); got != tt.want {
+				t.Errorf("SanitizeFile(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
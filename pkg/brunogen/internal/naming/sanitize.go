@@ -0,0 +1,77 @@
+package naming
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"unicode"
+)
+
+// maxNameLength bounds the length, in characters, of the names and file names
+// derived from protos and options
+const maxNameLength = 100
+
+// windowsReservedNames cannot be used as file names on Windows, whatever
+// their extension
+var windowsReservedNames = map[string]bool{
+	"con": true, "prn": true, "aux": true, "nul": true,
+	"com1": true, "com2": true, "com3": true, "com4": true, "com5": true, "com6": true, "com7": true, "com8": true, "com9": true,
+	"lpt1": true, "lpt2": true, "lpt3": true, "lpt4": true, "lpt5": true, "lpt6": true, "lpt7": true, "lpt8": true, "lpt9": true,
+}
+
+// Sanitize makes a name safe for Bruno meta blocks and bruno.json: a single
+// line without control characters, truncated to maxNameLength
+func Sanitize(name string) string {
+	name = strings.Join(strings.FieldsFunc(name, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsControl(r)
+	}), " ")
+	return truncateName(name)
+}
+
+// SanitizeFile makes a name safe as a file or folder name on every
+// platform. Runs of characters other than ASCII letters, digits, spaces and
+// -_.() are replaced with a dash, and names without any letter or digit left
+// fall back to a hash of the original, so distinct names stay distinct.
+func SanitizeFile(name string) string {
+	var b strings.Builder
+	meaningful := false
+	for _, r := range name {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			b.WriteRune(r)
+			meaningful = true
+		case strings.ContainsRune(" -_.()", r):
+			b.WriteRune(r)
+		case !strings.HasSuffix(b.String(), "-"):
+			b.WriteRune('-')
+		}
+	}
+	if !meaningful {
+		return nameHash(name)
+	}
+
+	// Windows drops trailing dots and spaces; leading dashes read as flags
+	sanitized := strings.TrimLeft(strings.TrimRight(b.String(), " .-"), " -")
+	base, _, _ := strings.Cut(sanitized, ".")
+	if windowsReservedNames[strings.ToLower(base)] {
+		sanitized = "_" + sanitized
+	}
+	return truncateName(sanitized)
+}
+
+// truncateName shortens names longer than maxNameLength deterministically,
+// ending them with a hash of the full name to keep them unique
+func truncateName(name string) string {
+	runes := []rune(name)
+	if len(runes) <= maxNameLength {
+		return name
+	}
+	hash := nameHash(name)
+	return strings.TrimRight(string(runes[:maxNameLength-len(hash)-1]), " .-") + "-" + hash
+}
+
+// nameHash returns a short, stable hash of a name
+func nameHash(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	return hex.EncodeToString(sum[:4])
+}
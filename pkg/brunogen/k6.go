@@ -0,0 +1,158 @@
+package brunogen
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/envgen"
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/grpcgen"
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/naming"
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// generateK6Scripts writes a k6 load test script per collection, k6-script.js,
+// with a scenario per service running a request function per method. The
+// base URL, path parameters and credentials are read from the environment,
+// like k6 run -e BASE_URL=https://api.example.com, and default to the values
+// of the first environment and the examples.
+func (s *state) generateK6Scripts(gen *protogen.Plugin, protoFiles []*protogen.File, requests []*httpRequest, environments []envgen.Environment, customName string) {
+	prefixes, collections := requestsByService(requests, func(req *httpRequest) string {
+		return s.collectionPrefix(req.file, req.service)
+	})
+	for _, prefix := range prefixes {
+		requests := collections[prefix]
+		g := gen.NewGeneratedFile(prefix+"k6-script.js", "")
+		g.P("// Load test of ", s.collectionDisplayName(protoFiles, prefix, customName), ", generated by protoc-gen-bruno.")
+		g.P("// Run with: k6 run -e ", k6EnvName(s.varName("base_url")), "=http://localhost:8080 k6-script.js")
+		g.P("// Each service is a scenario, sized with -e VUS=10 -e DURATION=1m.")
+		if len(environments) > 1 {
+			g.P("//")
+			g.P("// Environments, for ", k6EnvName(s.varName("base_url")), ":")
+			for _, env := range environments {
+				g.P("//   ", env.Name, ": ", env.HTTPURL)
+			}
+		}
+		g.P()
+		g.P(`import http from "k6/http";`)
+		g.P(`import { check } from "k6";`)
+		g.P()
+		for _, v := range s.requestVariables(requests, environments) {
+			g.P("const ", k6EnvName(v[0]), " = __ENV.", k6EnvName(v[0]), " || ", jsString(v[1]), ";")
+		}
+		g.P()
+
+		services, serviceRequests := requestsByService(requests, func(req *httpRequest) string {
+			return s.k6ServiceFunc(req.service)
+		})
+		g.P("export const options = {")
+		g.P("  scenarios: {")
+		for _, service := range services {
+			g.P("    ", naming.SnakeCase(service), ": {")
+			g.P(`      executor: "constant-vus",`)
+			g.P(`      vus: Number(__ENV.VUS || 1),`)
+			g.P(`      duration: __ENV.DURATION || "30s",`)
+			g.P(`      exec: "`, service, `",`)
+			g.P("    },")
+		}
+		g.P("  },")
+		g.P("};")
+
+		for _, service := range services {
+			for _, req := range serviceRequests[service] {
+				g.P()
+				g.P("// ", req.name)
+				if req.problem != "" {
+					g.P("// Warning: the google.api.http rule of this method is invalid: ", req.problem)
+				}
+				g.P("export function ", s.k6RequestFunc(req), "() {")
+				requestURL := s.requestURL(req)
+				for i, param := range req.query {
+					sep := "&"
+					if i == 0 {
+						sep = "?"
+					}
+					requestURL += sep + url.QueryEscape(param[0]) + "=" + param[1]
+				}
+				body := "null"
+				if req.body != "" {
+					g.P("  const payload = JSON.stringify(", strings.ReplaceAll(req.body, "\n", "\n  "), ");")
+					body = "payload"
+				}
+				g.P("  const res = http.request(", jsString(strings.ToUpper(req.verb)), ", ", k6Template(requestURL), ", ", body, ", {")
+				g.P("    headers: {")
+				for _, header := range req.headers {
+					g.P("      ", jsString(header[0]), ": ", k6Template(header[1]), ",")
+				}
+				if req.body != "" {
+					g.P(`      "Content-Type": "application/json",`)
+				}
+				g.P("    },")
+				g.P("    tags: { name: ", jsString(grpcgen.RPCName(req.method)), " },")
+				g.P("  });")
+				g.P("  check(res, { ", jsString(req.method.GoName+" status is 2xx"), ": (r) => r.status >= 200 && r.status < 300 });")
+				g.P("}")
+			}
+
+			g.P()
+			g.P("export function ", service, "() {")
+			for _, req := range serviceRequests[service] {
+				g.P("  ", s.k6RequestFunc(req), "();")
+			}
+			g.P("}")
+		}
+	}
+}
+
+// k6ServiceFunc returns the name of the scenario function of a service, like
+// userService, with the package for services colliding with another
+func (s *state) k6ServiceFunc(service *protogen.Service) string {
+	name := service.GoName
+	if s.collidingServices[service.Desc.FullName()] {
+		name = string(service.Desc.FullName())
+	}
+	return naming.CamelCase(name)
+}
+
+// k6RequestFunc returns the name of the function sending a request, like
+// userServiceGetUser
+func (s *state) k6RequestFunc(req *httpRequest) string {
+	return s.k6ServiceFunc(req.service) + req.method.GoName
+}
+
+// k6EnvName returns the environment variable and constant holding a
+// variable, like USER_ID for user_id
+func k6EnvName(name string) string {
+	return strings.ToUpper(naming.SnakeCase(name))
+}
+
+// k6Template returns a value as a JavaScript template literal, with variable
+// references as the constants holding them, or __ENV for process.env ones
+func k6Template(value string) string {
+	var b strings.Builder
+	b.WriteString("`")
+	escape := strings.NewReplacer("\\", "\\\\", "`", "\\`", "${", "\\${")
+	for {
+		loc := bruVarPattern.FindStringSubmatchIndex(value)
+		if loc == nil {
+			b.WriteString(escape.Replace(value))
+			break
+		}
+		b.WriteString(escape.Replace(value[:loc[0]]))
+		name := strings.TrimSpace(value[loc[2]:loc[3]])
+		if env, ok := strings.CutPrefix(name, "process.env."); ok {
+			b.WriteString("${__ENV." + env + "}")
+		} else {
+			b.WriteString("${" + k6EnvName(name) + "}")
+		}
+		value = value[loc[1]:]
+	}
+	b.WriteString("`")
+	return b.String()
+}
+
+// jsString returns a value as a JavaScript string literal
+func jsString(value string) string {
+	quoted, _ := json.Marshal(value)
+	return string(quoted)
+}
@@ -0,0 +1,91 @@
+package brunogen
+
+import (
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// libraryHelper is a request script that, with script_library, is generated
+// once as a function of lib/<file>.js and required by the requests using it
+type libraryHelper struct {
+	file   string
+	name   string
+	params []string
+	body   []string
+}
+
+// async reports whether the helper awaits, making its function async
+func (h libraryHelper) async() bool {
+	for _, line := range h.body {
+		if strings.Contains(line, "await ") {
+			return true
+		}
+	}
+	return false
+}
+
+// requestScript returns the snippet a request runs for a helper: its body, or
+// a call to the library function when script_library is enabled
+func (s *state) requestScript(h libraryHelper) []string {
+	if !s.scriptLibrary {
+		return h.body
+	}
+	call := `require("./lib/` + h.file + `.js").` + h.name + `(` + strings.Join(h.params, ", ") + `);`
+	if h.async() {
+		call = "await " + call
+	}
+	return []string{call}
+}
+
+// libraryHelpers returns the helpers used by the generated requests
+func (s *state) libraryHelpers() []libraryHelper {
+	var helpers []libraryHelper
+	if s.hmacSigningUsed {
+		helpers = append(helpers, s.hmacSignHelper())
+	}
+	if s.csrfEndpoint != "" {
+		helpers = append(helpers, s.csrfTokenHelper())
+	}
+	if s.idempotencyKey {
+		helpers = append(helpers, s.idempotencyKeyHelper())
+	}
+	if s.maxRetries > 0 {
+		helpers = append(helpers, s.retryHelper())
+	}
+	return helpers
+}
+
+// generateScriptLibrary writes one lib/<file>.js module per helper file,
+// exporting the helpers used by the collection
+func (s *state) generateScriptLibrary(gen *protogen.Plugin, prefix string) {
+	var files []string
+	byFile := make(map[string][]libraryHelper)
+	for _, h := range s.libraryHelpers() {
+		if byFile[h.file] == nil {
+			files = append(files, h.file)
+		}
+		byFile[h.file] = append(byFile[h.file], h)
+	}
+
+	for _, file := range files {
+		g := gen.NewGeneratedFile(prefix+"lib/"+file+".js", "")
+		g.P("// Helpers shared by the request scripts of this collection")
+		var names []string
+		for _, h := range byFile[file] {
+			keyword := "function"
+			if h.async() {
+				keyword = "async function"
+			}
+			g.P("")
+			g.P(keyword, " ", h.name, "(", strings.Join(h.params, ", "), ") {")
+			for _, line := range h.body {
+				g.P("  ", line)
+			}
+			g.P("}")
+			names = append(names, h.name)
+		}
+		g.P("")
+		g.P("module.exports = { ", strings.Join(names, ", "), " };")
+	}
+}
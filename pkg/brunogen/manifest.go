@@ -0,0 +1,68 @@
+package brunogen
+
+import (
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// manifestRequest describes a generated request in manifest.json
+type manifestRequest struct {
+	File     string `json:"file"`
+	Name     string `json:"name"`
+	Service  string `json:"service"`
+	Method   string `json:"method"`
+	Protocol string `json:"protocol"`
+	Verb     string `json:"verb,omitempty"`
+	Path     string `json:"path,omitempty"`
+}
+
+// recordRequest adds a generated request to the manifest of its collection.
+// httpMethod is "grpc" for gRPC requests.
+func (s *state) recordRequest(prefix string, filename string, method *protogen.Method, httpMethod string, path string) {
+	if !s.manifest && !s.stats && !s.dryRun && s.templates == nil {
+		return
+	}
+	request := manifestRequest{
+		File:     filename,
+		Name:     s.requestName(method, httpMethod),
+		Service:  string(method.Parent.Desc.FullName()),
+		Method:   string(method.Desc.Name()),
+		Protocol: "grpc",
+	}
+	if httpMethod != "grpc" {
+		request.Protocol = "http"
+		request.Verb = strings.ToUpper(httpMethod)
+		request.Path = path
+	}
+	s.manifestRequests[prefix] = append(s.manifestRequests[prefix], request)
+	s.templateRequests[filename] = request
+}
+
+// generateManifests writes a manifest.json per collection listing every
+// generated request with its service, method, HTTP verb and path, and file, so
+// tooling and reviewers can see the coverage of a collection at a glance
+func (s *state) generateManifests(gen *protogen.Plugin) error {
+	for prefix, requests := range s.manifestRequests {
+		// Files are relative to the manifest, wherever rewrite_path moves them
+		dir := path.Dir(s.rewritePath(prefix + "manifest.json"))
+		for i := range requests {
+			requests[i].File = strings.TrimPrefix(s.rewritePath(requests[i].File), dir+"/")
+		}
+		sort.SliceStable(requests, func(i, j int) bool {
+			return requests[i].File < requests[j].File
+		})
+		content, err := json.MarshalIndent(struct {
+			Requests []manifestRequest `json:"requests"`
+		}{requests}, "", "  ")
+		if err != nil {
+			return err
+		}
+		g := gen.NewGeneratedFile(prefix+"manifest.json", "")
+		g.P(string(content))
+	}
+	return nil
+}
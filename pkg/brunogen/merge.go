@@ -0,0 +1,393 @@
+package brunogen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// Supported ways of writing over existing files
+const (
+	writeModeOverwrite    = "overwrite"
+	writeModeSkipExisting = "skip-existing"
+	writeModeMerge        = "merge"
+)
+
+// Markers delimiting the generated part of script, tests and docs blocks in
+// merged files, so code and notes added around it survive regeneration
+const (
+	generatedBegin = "protoc-gen-bruno:generated:begin"
+	generatedEnd   = "protoc-gen-bruno:generated:end"
+)
+
+// bodyHashesFile records the hash of the generated bodies of merged .bru files,
+// so bodies left as generated are told apart from edited ones
+const bodyHashesFile = "body-hashes.json"
+
+// bodyHash is the hash of a generated body block in body-hashes.json
+type bodyHash struct {
+	File   string `json:"file"`
+	Block  string `json:"block"`
+	SHA256 string `json:"sha256"`
+}
+
+// machineOwnedBlocks are always regenerated in merged .bru files: the request
+// line and the identity of the request
+var machineOwnedBlocks = map[string]bool{
+	"meta": true, "get": true, "post": true, "put": true, "patch": true, "delete": true,
+	"options": true, "head": true, "grpc": true,
+}
+
+// markedBlocks hold generated content between markers in merged .bru files,
+// with the comment syntax of their content
+var markedBlocks = map[string][2]string{
+	"script:pre-request":   {"// ", ""},
+	"script:post-response": {"// ", ""},
+	"tests":                {"// ", ""},
+	"docs":                 {"<!-- ", " -->"},
+}
+
+// dictionaryBlocks are merged entry by entry in merged .bru files: generated
+// entries are added or renamed with the protos, and edited values are kept
+var dictionaryBlocks = map[string]bool{
+	"vars": true, "vars:secret": true, "vars:pre-request": true, "headers": true,
+	"params:query": true, "metadata": true,
+}
+
+// bruBlock is a top-level block of a .bru file, such as "post {" or
+// "vars:secret [", and its lines
+type bruBlock struct {
+	name  string
+	list  bool
+	lines []string
+}
+
+// parseBru splits a .bru file into its top-level blocks
+func parseBru(content string) []bruBlock {
+	var blocks []bruBlock
+	var current *bruBlock
+	for _, line := range strings.Split(content, "\n") {
+		switch {
+		case current == nil && strings.HasSuffix(line, " {") && !strings.HasPrefix(line, " "):
+			current = &bruBlock{name: strings.TrimSuffix(line, " {")}
+		case current == nil && strings.HasSuffix(line, " [") && !strings.HasPrefix(line, " "):
+			current = &bruBlock{name: strings.TrimSuffix(line, " ["), list: true}
+		case current != nil && line == "}" && !current.list, current != nil && line == "]" && current.list:
+			blocks = append(blocks, *current)
+			current = nil
+		case current != nil:
+			current.lines = append(current.lines, line)
+		}
+	}
+	return blocks
+}
+
+// formatBru writes blocks back in the .bru format
+func formatBru(blocks []bruBlock) string {
+	var parts []string
+	for _, block := range blocks {
+		open, close := " {", "}"
+		if block.list {
+			open, close = " [", "]"
+		}
+		parts = append(parts, strings.Join(append(append([]string{block.name + open}, block.lines...), close), "\n"))
+	}
+	return strings.Join(parts, "\n\n") + "\n"
+}
+
+// mergeBru merges a regenerated .bru file into the existing one. Machine-owned
+// blocks are replaced, keeping the seq the request was moved to. The generated
+// part of script, tests and docs blocks is replaced between markers, and blocks
+// the user has not changed since they were generated are put under markers.
+// Bodies are replaced while they still match the hash of the body generated
+// last, from bodies by block name. Other blocks, such as hand-tuned bodies,
+// headers and assertions, are kept; blocks new to the generated file are added.
+func mergeBru(existing string, generated string, bodies map[string]string) string {
+	kept := make(map[string]bruBlock)
+	var order []string
+	for _, block := range parseBru(existing) {
+		kept[block.name] = block
+		order = append(order, block.name)
+	}
+
+	merged := make(map[string]bruBlock)
+	for _, block := range parseBru(generated) {
+		old, ok := kept[block.name]
+		switch {
+		case !ok:
+			order = append(order, block.name)
+			merged[block.name] = markBlock(block)
+		case block.name == "meta":
+			merged[block.name] = keepSeq(block, old)
+		case machineOwnedBlocks[block.name]:
+			merged[block.name] = block
+		case dictionaryBlocks[block.name]:
+			merged[block.name] = mergeEntries(old, block)
+		case isBodyBlock(block.name) && bodies[block.name] == linesHash(old.lines):
+			merged[block.name] = block
+		case hasMarkers(old):
+			merged[block.name] = replaceMarked(old, block)
+		case equalLines(old.lines, block.lines):
+			merged[block.name] = markBlock(block)
+		default:
+			merged[block.name] = old
+		}
+	}
+
+	var blocks []bruBlock
+	for _, name := range order {
+		if block, ok := merged[name]; ok {
+			blocks = append(blocks, block)
+		} else if block, ok := kept[name]; ok && !machineOwnedBlocks[name] {
+			// Blocks the generator no longer writes are the user's
+			blocks = append(blocks, block)
+		}
+	}
+	return formatBru(blocks)
+}
+
+// markerLine returns a marker line in the comment syntax of a block
+func markerLine(name string, marker string) string {
+	syntax := markedBlocks[name]
+	return "  " + syntax[0] + marker + syntax[1]
+}
+
+// markBlock puts the generated content of a marked block between markers
+func markBlock(block bruBlock) bruBlock {
+	if _, ok := markedBlocks[block.name]; !ok {
+		return block
+	}
+	lines := []string{markerLine(block.name, generatedBegin)}
+	lines = append(lines, block.lines...)
+	lines = append(lines, markerLine(block.name, generatedEnd))
+	return bruBlock{name: block.name, list: block.list, lines: lines}
+}
+
+// hasMarkers reports whether an existing block holds generated content
+// between markers
+func hasMarkers(block bruBlock) bool {
+	if _, ok := markedBlocks[block.name]; !ok {
+		return false
+	}
+	begin, end := markerIndexes(block)
+	return begin >= 0 && end > begin
+}
+
+// markerIndexes returns the line indexes of the markers of a block, or -1
+func markerIndexes(block bruBlock) (begin, end int) {
+	begin, end = -1, -1
+	for i, line := range block.lines {
+		switch strings.TrimSpace(line) {
+		case strings.TrimSpace(markerLine(block.name, generatedBegin)):
+			begin = i
+		case strings.TrimSpace(markerLine(block.name, generatedEnd)):
+			end = i
+		}
+	}
+	return begin, end
+}
+
+// replaceMarked replaces the content between the markers of an existing block
+// with the regenerated content
+func replaceMarked(old bruBlock, block bruBlock) bruBlock {
+	begin, end := markerIndexes(old)
+	lines := append([]string{}, old.lines[:begin+1]...)
+	lines = append(lines, block.lines...)
+	lines = append(lines, old.lines[end:]...)
+	return bruBlock{name: block.name, list: block.list, lines: lines}
+}
+
+// keepSeq returns the regenerated meta block with the seq of the existing one,
+// since reordering requests in Bruno rewrites it
+func keepSeq(block bruBlock, old bruBlock) bruBlock {
+	var seq string
+	for _, line := range old.lines {
+		if strings.HasPrefix(line, "  seq: ") {
+			seq = line
+		}
+	}
+	if seq == "" {
+		return block
+	}
+	lines := append([]string{}, block.lines...)
+	for i, line := range lines {
+		if strings.HasPrefix(line, "  seq: ") {
+			lines[i] = seq
+		}
+	}
+	return bruBlock{name: block.name, list: block.list, lines: lines}
+}
+
+// entryKey returns the key of a dictionary or list entry, ignoring whether it
+// is disabled ("~key: value")
+func entryKey(line string) string {
+	key, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(line), "~"), ":")
+	return strings.TrimSuffix(strings.TrimSpace(key), ",")
+}
+
+// mergeEntries merges the entries of a dictionary block: the generated entries
+// in order, with the existing line of the ones already there, followed by the
+// entries only the existing block has
+func mergeEntries(old bruBlock, block bruBlock) bruBlock {
+	existing := make(map[string]string)
+	for _, line := range old.lines {
+		existing[entryKey(line)] = line
+	}
+	var lines []string
+	generated := make(map[string]bool)
+	for _, line := range block.lines {
+		key := entryKey(line)
+		generated[key] = true
+		if kept, ok := existing[key]; ok {
+			line = kept
+		}
+		lines = append(lines, line)
+	}
+	for _, line := range old.lines {
+		if !generated[entryKey(line)] {
+			lines = append(lines, line)
+		}
+	}
+	if block.list {
+		// List entries are separated by commas, except the last one
+		for i := range lines {
+			lines[i] = strings.TrimSuffix(lines[i], ",")
+			if i < len(lines)-1 {
+				lines[i] += ","
+			}
+		}
+	}
+	return bruBlock{name: block.name, list: block.list, lines: lines}
+}
+
+// equalLines reports whether two blocks have the same content
+func equalLines(a []string, b []string) bool {
+	return strings.Join(a, "\n") == strings.Join(b, "\n")
+}
+
+// isBodyBlock reports whether a block holds the body of a request, such as
+// body:json, body:grpc or the body of Bruno 1.x gRPC requests
+func isBodyBlock(name string) bool {
+	return name == "body" || strings.HasPrefix(name, "body:")
+}
+
+// linesHash returns the SHA-256 of the content of a block
+func linesHash(lines []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// generatedBodyHashes returns the hashes of the body blocks of a generated
+// .bru file
+func generatedBodyHashes(file string, content string) []bodyHash {
+	var hashes []bodyHash
+	for _, block := range parseBru(content) {
+		if isBodyBlock(block.name) {
+			hashes = append(hashes, bodyHash{File: file, Block: block.name, SHA256: linesHash(block.lines)})
+		}
+	}
+	return hashes
+}
+
+// readBodyHashes returns the body hashes of the previous merge in out_dir, by
+// file and block name, or none before the first merge
+func (s *state) readBodyHashes() (map[string]map[string]string, error) {
+	bodies := make(map[string]map[string]string)
+	content, err := os.ReadFile(filepath.Join(s.outDir, filepath.FromSlash(s.outPrefix+bodyHashesFile)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return bodies, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var previous struct {
+		Bodies []bodyHash `json:"bodies"`
+	}
+	if err := json.Unmarshal(content, &previous); err != nil {
+		return nil, fmt.Errorf("reading the previous %s: %w", bodyHashesFile, err)
+	}
+	for _, hash := range previous.Bodies {
+		if bodies[hash.File] == nil {
+			bodies[hash.File] = make(map[string]string)
+		}
+		bodies[hash.File][hash.Block] = hash.SHA256
+	}
+	return bodies, nil
+}
+
+// bodyHashesResponseFile returns the body-hashes.json of the generated bodies
+func (s *state) bodyHashesResponseFile(hashes []bodyHash) (*pluginpb.CodeGeneratorResponse_File, error) {
+	sort.Slice(hashes, func(i, j int) bool {
+		if hashes[i].File != hashes[j].File {
+			return hashes[i].File < hashes[j].File
+		}
+		return hashes[i].Block < hashes[j].Block
+	})
+	content, err := json.MarshalIndent(struct {
+		Bodies []bodyHash `json:"bodies"`
+	}{hashes}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &pluginpb.CodeGeneratorResponse_File{
+		Name:    proto.String(s.outPrefix + bodyHashesFile),
+		Content: proto.String(string(content) + "\n"),
+	}, nil
+}
+
+// applyWriteMode adjusts the response to the files already in the output
+// directory: with skip-existing they are left untouched, and with merge the
+// .bru files are merged with them so manual edits survive regeneration, and a
+// body-hashes.json records the generated bodies for the next merge
+func (s *state) applyWriteMode(resp *pluginpb.CodeGeneratorResponse) error {
+	if s.writeMode == writeModeOverwrite {
+		return nil
+	}
+	var bodies map[string]map[string]string
+	var hashes []bodyHash
+	if s.writeMode == writeModeMerge {
+		var err error
+		if bodies, err = s.readBodyHashes(); err != nil {
+			return err
+		}
+	}
+	var files []*pluginpb.CodeGeneratorResponse_File
+	for _, file := range resp.File {
+		if s.writeMode == writeModeMerge && strings.HasSuffix(file.GetName(), ".bru") {
+			hashes = append(hashes, generatedBodyHashes(file.GetName(), file.GetContent())...)
+		}
+		existing, err := os.ReadFile(filepath.Join(s.outDir, filepath.FromSlash(file.GetName())))
+		if errors.Is(err, fs.ErrNotExist) {
+			files = append(files, file)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if s.writeMode == writeModeMerge {
+			if strings.HasSuffix(file.GetName(), ".bru") {
+				file.Content = proto.String(mergeBru(normalizeContent(string(existing)), file.GetContent(), bodies[file.GetName()]))
+			}
+			files = append(files, file)
+		}
+	}
+	if s.writeMode == writeModeMerge {
+		file, err := s.bodyHashesResponseFile(hashes)
+		if err != nil {
+			return err
+		}
+		files = append(files, file)
+	}
+	resp.File = files
+	return nil
+}
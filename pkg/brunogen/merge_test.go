@@ -0,0 +1,222 @@
+package brunogen
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+func TestParseBru(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []bruBlock
+	}{
+		{
+			name:    "dictionary and text blocks",
+			content: "meta {\n  name: Get User\n  seq: 1\n}\n\ndocs {\n  Returns a user.\n}\n",
+			want: []bruBlock{
+				{name: "meta", lines: []string{"  name: Get User", "  seq: 1"}},
+				{name: "docs", lines: []string{"  Returns a user."}},
+			},
+		},
+		{
+			name:    "list block",
+			content: "vars:secret [\n  token,\n  api_key\n]\n",
+			want: []bruBlock{
+				{name: "vars:secret", list: true, lines: []string{"  token,", "  api_key"}},
+			},
+		},
+		{
+			name:    "nested braces stay in the block",
+			content: "body:json {\n  {\n    \"user\": {}\n  }\n}\n",
+			want: []bruBlock{
+				{name: "body:json", lines: []string{"  {", "    \"user\": {}", "  }"}},
+			},
+		},
+		{
+			name:    "lines between blocks are dropped",
+			content: "stray\nmeta {\n  seq: 2\n}\n",
+			want: []bruBlock{
+				{name: "meta", lines: []string{"  seq: 2"}},
+			},
+		},
+		{
+			name:    "unterminated block is dropped",
+			content: "meta {\n  seq: 2\n",
+			want:    nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseBru(tt.content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseBru() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplaceMarked(t *testing.T) {
+	tests := []struct {
+		name  string
+		old   bruBlock
+		block bruBlock
+		want  []string
+	}{
+		{
+			name: "code around the markers is kept",
+			old: bruBlock{name: "tests", lines: []string{
+				"  // mine",
+				"  // " + generatedBegin,
+				"  old();",
+				"  // " + generatedEnd,
+				"  more();",
+			}},
+			block: bruBlock{name: "tests", lines: []string{"  new();", "  newer();"}},
+			want: []string{
+				"  // mine",
+				"  // " + generatedBegin,
+				"  new();",
+				"  newer();",
+				"  // " + generatedEnd,
+				"  more();",
+			},
+		},
+		{
+			name: "empty generated content",
+			old: bruBlock{name: "docs", lines: []string{
+				"  <!-- " + generatedBegin + " -->",
+				"  Old docs.",
+				"  <!-- " + generatedEnd + " -->",
+			}},
+			block: bruBlock{name: "docs"},
+			want: []string{
+				"  <!-- " + generatedBegin + " -->",
+				"  <!-- " + generatedEnd + " -->",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := replaceMarked(tt.old, tt.block)
+			if !reflect.DeepEqual(got.lines, tt.want) {
+				t.Errorf("replaceMarked() = %q, want %q", got.lines, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeBru(t *testing.T) {
+	generatedBody := "body:json {\n  {\n    \"name\": \"example_name\"\n  }\n}\n"
+	tests := []struct {
+		name      string
+		existing  string
+		generated string
+		bodies    map[string]string
+		want      string
+	}{
+		{
+			name:      "request line is regenerated and seq kept",
+			existing:  "meta {\n  name: Old\n  seq: 7\n}\n\nget {\n  url: {{base_url}}/v1/old\n}\n",
+			generated: "meta {\n  name: New\n  seq: 1\n}\n\nget {\n  url: {{base_url}}/v1/new\n}\n",
+			want:      "meta {\n  name: New\n  seq: 7\n}\n\nget {\n  url: {{base_url}}/v1/new\n}\n",
+		},
+		{
+			name:      "edited header values are kept and new headers added",
+			existing:  "headers {\n  X-Tenant: mine\n  X-Extra: 1\n}\n",
+			generated: "headers {\n  X-Tenant: {{tenant}}\n  X-Trace: on\n}\n",
+			want:      "headers {\n  X-Tenant: mine\n  X-Trace: on\n  X-Extra: 1\n}\n",
+		},
+		{
+			name:      "unchanged script is put under markers",
+			existing:  "tests {\n  check();\n}\n",
+			generated: "tests {\n  check();\n}\n",
+			want:      "tests {\n  // " + generatedBegin + "\n  check();\n  // " + generatedEnd + "\n}\n",
+		},
+		{
+			name:      "edited script without markers is kept",
+			existing:  "tests {\n  mine();\n}\n",
+			generated: "tests {\n  check();\n}\n",
+			want:      "tests {\n  mine();\n}\n",
+		},
+		{
+			name:      "body matching its generated hash is regenerated",
+			existing:  "body:json {\n  {\n    \"name\": \"old\"\n  }\n}\n",
+			generated: generatedBody,
+			bodies:    map[string]string{"body:json": linesHash([]string{"  {", "    \"name\": \"old\"", "  }"})},
+			want:      generatedBody,
+		},
+		{
+			name:      "edited body is kept",
+			existing:  "body:json {\n  {\n    \"name\": \"mine\"\n  }\n}\n",
+			generated: generatedBody,
+			bodies:    map[string]string{"body:json": linesHash([]string{"  {", "    \"name\": \"old\"", "  }"})},
+			want:      "body:json {\n  {\n    \"name\": \"mine\"\n  }\n}\n",
+		},
+		{
+			name:      "body without a recorded hash is kept",
+			existing:  "body:json {\n  {\n    \"name\": \"old\"\n  }\n}\n",
+			generated: generatedBody,
+			want:      "body:json {\n  {\n    \"name\": \"old\"\n  }\n}\n",
+		},
+		{
+			name:      "blocks the generator no longer writes are kept",
+			existing:  "assert {\n  res.status: eq 201\n}\n",
+			generated: "meta {\n  seq: 1\n}\n",
+			want:      "assert {\n  res.status: eq 201\n}\n\nmeta {\n  seq: 1\n}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeBru(tt.existing, tt.generated, tt.bodies); got != tt.want {
+				t.Errorf("mergeBru() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyWriteModeMergeRegeneratesBodies(t *testing.T) {
+	s := newState()
+	s.outDir, s.writeMode = t.TempDir(), writeModeMerge
+
+	generate := func(name string) string {
+		resp := &pluginpb.CodeGeneratorResponse{File: []*pluginpb.CodeGeneratorResponse_File{{
+			Name:    proto.String("User/CreateUser.bru"),
+			Content: proto.String("body:json {\n  {\n    \"name\": \"" + name + "\"\n  }\n}\n"),
+		}}}
+		if err := s.applyWriteMode(resp); err != nil {
+			t.Fatal(err)
+		}
+		var content string
+		for _, file := range resp.File {
+			path := filepath.Join(s.outDir, file.GetName())
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(file.GetContent()), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if file.GetName() == "User/CreateUser.bru" {
+				content = file.GetContent()
+			}
+		}
+		return content
+	}
+
+	generate("first")
+	if got, want := generate("second"), "body:json {\n  {\n    \"name\": \"second\"\n  }\n}\n"; got != want {
+		t.Fatalf("unedited body: got\n%s\nwant\n%s", got, want)
+	}
+
+	edited := "body:json {\n  {\n    \"name\": \"mine\"\n  }\n}\n"
+	if err := os.WriteFile(filepath.Join(s.outDir, "User/CreateUser.bru"), []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := generate("third"); got != edited {
+		t.Fatalf("edited body: got\n%s\nwant\n%s", got, edited)
+	}
+}
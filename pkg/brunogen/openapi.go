@@ -0,0 +1,231 @@
+package brunogen
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/naming"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-openapiv2/options"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// openAPIAuth is the Bruno auth derived from an OpenAPI v2 security requirement
+type openAPIAuth struct {
+	// mode is the Bruno auth mode: none, basic, apikey or oauth2
+	mode string
+	// entries are the keys and values of the auth:<mode> block
+	entries [][2]string
+}
+
+// loadOpenAPISecurity collects the security definitions declared in any file of
+// the request, since they are commonly kept in a dedicated proto file
+func (s *state) loadOpenAPISecurity(files []*protogen.File) {
+	s.openAPISchemes = make(map[string]*options.SecurityScheme)
+	s.openAPIDefaultSecurity = nil
+
+	for _, f := range files {
+		swagger := fileSwagger(f.Desc)
+		if swagger == nil {
+			continue
+		}
+		for name, scheme := range swagger.GetSecurityDefinitions().GetSecurity() {
+			s.openAPISchemes[name] = scheme
+		}
+		if s.openAPIDefaultSecurity == nil && len(swagger.GetSecurity()) > 0 {
+			s.openAPIDefaultSecurity = swagger.GetSecurity()
+		}
+	}
+}
+
+// fileSwagger returns the openapiv2_swagger option of a file, if any
+func fileSwagger(file protoreflect.FileDescriptor) *options.Swagger {
+	opts := file.Options()
+	if !proto.HasExtension(opts, options.E_Openapiv2Swagger) {
+		return nil
+	}
+	return proto.GetExtension(opts, options.E_Openapiv2Swagger).(*options.Swagger)
+}
+
+// methodOperation returns the openapiv2_operation option of a method, if any
+func methodOperation(method *protogen.Method) *options.Operation {
+	opts := method.Desc.Options()
+	if !proto.HasExtension(opts, options.E_Openapiv2Operation) {
+		return nil
+	}
+	return proto.GetExtension(opts, options.E_Openapiv2Operation).(*options.Operation)
+}
+
+// serviceTag returns the openapiv2_tag option of a service, if any
+func serviceTag(service *protogen.Service) *options.Tag {
+	opts := service.Desc.Options()
+	if !proto.HasExtension(opts, options.E_Openapiv2Tag) {
+		return nil
+	}
+	return proto.GetExtension(opts, options.E_Openapiv2Tag).(*options.Tag)
+}
+
+// methodTagFolder returns the folder of the first OpenAPI tag of a method with
+// layout=tag, as Swagger UI lists the operation under it. Like
+// protoc-gen-openapiv2, methods without operation tags fall back to the name of
+// their service's openapiv2_tag; methods with neither stay in their service
+// folder.
+func (s *state) methodTagFolder(method *protogen.Method) (groupFolder, bool) {
+	service := serviceTag(method.Parent)
+	tag := service.GetName()
+	if tags := methodOperation(method).GetTags(); len(tags) > 0 {
+		tag = tags[0]
+	}
+	if tag == "" {
+		return groupFolder{}, false
+	}
+
+	// Describe the folder as the Swagger docs describe the tag
+	docs := ""
+	if tag == service.GetName() {
+		docs = service.GetDescription()
+	}
+	for _, t := range fileSwagger(method.Desc.ParentFile()).GetTags() {
+		if t.GetName() == tag && t.GetDescription() != "" {
+			docs = t.GetDescription()
+		}
+	}
+	return groupFolder{
+		folder: naming.SanitizeFile(s.fileCase(tag)),
+		name:   tag,
+		docs:   docs,
+	}, true
+}
+
+// methodOpenAPIAuth resolves the security requirement that applies to a method
+// (operation, then file, then any file in the request) and translates it into
+// Bruno auth. It returns nil when no security is documented for the method.
+func (s *state) methodOpenAPIAuth(method *protogen.Method) *openAPIAuth {
+	requirements := methodOperation(method).GetSecurity()
+	if len(requirements) == 0 {
+		requirements = fileSwagger(method.Desc.ParentFile()).GetSecurity()
+	}
+	if len(requirements) == 0 {
+		requirements = s.openAPIDefaultSecurity
+	}
+	if len(requirements) == 0 {
+		return nil
+	}
+
+	// Bruno supports a single scheme per request, so use the first alternative
+	// and, within it, the first scheme by name
+	requirement := requirements[0].GetSecurityRequirement()
+	if len(requirement) == 0 {
+		// An empty requirement marks the operation as public
+		return &openAPIAuth{mode: "none"}
+	}
+	var names []string
+	for name := range requirement {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	name := names[0]
+	scheme := s.openAPISchemes[name]
+	if scheme == nil {
+		return nil
+	}
+
+	switch scheme.GetType() {
+	case options.SecurityScheme_TYPE_BASIC:
+		return &openAPIAuth{mode: "basic", entries: [][2]string{
+			{"username", s.credentialRef("username")},
+			{"password", s.credentialRef("password")},
+		}}
+	case options.SecurityScheme_TYPE_API_KEY:
+		placement := "header"
+		if scheme.GetIn() == options.SecurityScheme_IN_QUERY {
+			placement = "queryparams"
+		}
+		return &openAPIAuth{mode: "apikey", entries: [][2]string{
+			{"key", scheme.GetName()},
+			{"value", s.credentialRef(naming.SnakeCase(name))},
+			{"placement", placement},
+		}}
+	case options.SecurityScheme_TYPE_OAUTH2:
+		entries := [][2]string{{"grant_type", oauth2GrantType(scheme.GetFlow())}}
+		if scheme.GetFlow() == options.SecurityScheme_FLOW_ACCESS_CODE || scheme.GetFlow() == options.SecurityScheme_FLOW_IMPLICIT {
+			entries = append(entries,
+				[2]string{"callback_url", s.varRef("oauth2_callback_url")},
+				[2]string{"authorization_url", scheme.GetAuthorizationUrl()},
+			)
+		}
+		if scheme.GetFlow() != options.SecurityScheme_FLOW_IMPLICIT {
+			entries = append(entries, [2]string{"access_token_url", scheme.GetTokenUrl()})
+		}
+		if scheme.GetFlow() == options.SecurityScheme_FLOW_PASSWORD {
+			entries = append(entries,
+				[2]string{"username", s.credentialRef("username")},
+				[2]string{"password", s.credentialRef("password")},
+			)
+		}
+		entries = append(entries,
+			[2]string{"client_id", s.credentialRef("oauth2_client_id")},
+			[2]string{"client_secret", s.credentialRef("oauth2_client_secret")},
+			[2]string{"scope", strings.Join(requirement[name].GetScope(), " ")},
+		)
+		return &openAPIAuth{mode: "oauth2", entries: entries}
+	}
+
+	return nil
+}
+
+// oauth2GrantType maps an OpenAPI v2 OAuth2 flow to the Bruno grant type
+func oauth2GrantType(flow options.SecurityScheme_Flow) string {
+	switch flow {
+	case options.SecurityScheme_FLOW_ACCESS_CODE:
+		return "authorization_code"
+	case options.SecurityScheme_FLOW_PASSWORD:
+		return "password"
+	case options.SecurityScheme_FLOW_IMPLICIT:
+		return "implicit"
+	default:
+		return "client_credentials"
+	}
+}
+
+// openAPISecurityVars returns the environment variables referenced by the
+// collected security definitions, sorted by scheme name
+func (s *state) openAPISecurityVars() []environmentVar {
+	var names []string
+	for name := range s.openAPISchemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var vars []environmentVar
+	for _, name := range names {
+		scheme := s.openAPISchemes[name]
+		switch scheme.GetType() {
+		case options.SecurityScheme_TYPE_BASIC:
+			vars = append(vars,
+				environmentVar{name: s.varName("username"), credential: true},
+				environmentVar{name: s.varName("password"), secret: true, credential: true},
+			)
+		case options.SecurityScheme_TYPE_API_KEY:
+			vars = append(vars, environmentVar{name: s.varName(naming.SnakeCase(name)), secret: true, credential: true})
+		case options.SecurityScheme_TYPE_OAUTH2:
+			if scheme.GetFlow() == options.SecurityScheme_FLOW_ACCESS_CODE || scheme.GetFlow() == options.SecurityScheme_FLOW_IMPLICIT {
+				vars = append(vars, environmentVar{name: s.varName("oauth2_callback_url")})
+			}
+			if scheme.GetFlow() == options.SecurityScheme_FLOW_PASSWORD {
+				vars = append(vars,
+					environmentVar{name: s.varName("username"), credential: true},
+					environmentVar{name: s.varName("password"), secret: true, credential: true},
+				)
+			}
+			vars = append(vars,
+				environmentVar{name: s.varName("oauth2_client_id"), credential: true},
+				environmentVar{name: s.varName("oauth2_client_secret"), secret: true, credential: true},
+			)
+		}
+	}
+
+	return vars
+}
@@ -0,0 +1,386 @@
+package brunogen
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/envgen"
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/examples"
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/grpcgen"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Security schemes of the OpenAPI documents, for the bearer and apikey auth
+const (
+	openAPIBearerScheme = "bearerAuth"
+	openAPIKeyScheme    = "apiKeyAuth"
+)
+
+// yamlMap is a YAML mapping that keeps the order of its keys
+type yamlMap []yamlField
+
+// yamlField is a key and value of a yamlMap
+type yamlField struct {
+	key   string
+	value any
+}
+
+// yamlJSON is a JSON value, written as is since JSON is YAML
+type yamlJSON string
+
+// generateOpenAPISpecs writes an OpenAPI 3.1 document per collection,
+// openapi.yaml, describing the same paths, parameters and example bodies as
+// its HTTP requests
+func (s *state) generateOpenAPISpecs(gen *protogen.Plugin, protoFiles []*protogen.File, environments []envgen.Environment, customName string) error {
+	// The requests are built again for the spec, and the collection records
+	// the methods it skips
+	skipped := len(s.skippedMethods)
+	requests, err := s.httpRequests(protoFiles)
+	s.skippedMethods = s.skippedMethods[:skipped]
+	if err != nil {
+		return err
+	}
+
+	prefixes, collections := requestsByService(requests, func(req *httpRequest) string {
+		return s.collectionPrefix(req.file, req.service)
+	})
+	for _, prefix := range prefixes {
+		var b strings.Builder
+		writeYAML(&b, 0, s.openAPIDocument(protoFiles, prefix, collections[prefix], environments, customName))
+		gen.NewGeneratedFile(prefix+"openapi.yaml", "").P(strings.TrimSuffix(b.String(), "\n"))
+	}
+	return nil
+}
+
+// openAPIDocument returns the OpenAPI document of the HTTP requests of a
+// collection, with the environments as servers
+func (s *state) openAPIDocument(protoFiles []*protogen.File, prefix string, requests []*httpRequest, environments []envgen.Environment, customName string) yamlMap {
+	version := "1.0.0"
+	if _, v := packageAPIVersion(string(requests[0].file.Desc.Package())); v != "" {
+		version = v
+	}
+	doc := yamlMap{
+		{"openapi", "3.1.0"},
+		{"info", yamlMap{
+			{"title", s.collectionDisplayName(protoFiles, prefix, customName)},
+			{"version", version},
+		}},
+	}
+
+	var servers []any
+	for _, env := range environments {
+		servers = append(servers, yamlMap{{"url", env.HTTPURL + env.BasePath}, {"description", env.Name}})
+	}
+	if len(servers) == 0 {
+		servers = append(servers, yamlMap{{"url", s.curlBaseURL}})
+	}
+	doc = append(doc, yamlField{"servers", servers})
+
+	var tags []any
+	var paths yamlMap
+	schemes := make(map[string]bool)
+	for _, req := range requests {
+		tag := s.serviceDisplayName(req.service)
+		if !slices.ContainsFunc(tags, func(t any) bool { return t.(yamlMap)[0].value == tag }) {
+			t := yamlMap{{"name", tag}}
+			if comment := firstSentence(string(req.service.Comments.Leading)); comment != "" {
+				t = append(t, yamlField{"description", comment})
+			}
+			tags = append(tags, t)
+		}
+
+		path := openAPIPath(req.urlPath)
+		i := slices.IndexFunc(paths, func(f yamlField) bool { return f.key == path })
+		if i < 0 {
+			paths = append(paths, yamlField{path, yamlMap{}})
+			i = len(paths) - 1
+		}
+		operations := paths[i].value.(yamlMap)
+		if slices.ContainsFunc(operations, func(f yamlField) bool { return f.key == req.verb }) {
+			s.tracef("method %s: left out of openapi.yaml, another method has %s %s", grpcgen.RPCName(req.method), strings.ToUpper(req.verb), req.path)
+			continue
+		}
+		paths[i].value = append(operations, yamlField{req.verb, s.openAPIOperation(req, tag)})
+
+		switch req.authMode {
+		case "bearer", "apikey":
+			schemes[req.authMode] = true
+		}
+	}
+	doc = append(doc, yamlField{"tags", tags}, yamlField{"paths", paths})
+
+	var securitySchemes yamlMap
+	if schemes["bearer"] {
+		securitySchemes = append(securitySchemes, yamlField{openAPIBearerScheme, yamlMap{{"type", "http"}, {"scheme", "bearer"}}})
+	}
+	if schemes["apikey"] {
+		in := "header"
+		if s.apiKeyPlacement == "queryparams" {
+			in = "query"
+		}
+		securitySchemes = append(securitySchemes, yamlField{openAPIKeyScheme, yamlMap{{"type", "apiKey"}, {"in", in}, {"name", s.apiKeyName}}})
+	}
+	if len(securitySchemes) > 0 {
+		doc = append(doc, yamlField{"components", yamlMap{{"securitySchemes", securitySchemes}}})
+	}
+	return doc
+}
+
+// openAPIOperation returns the OpenAPI operation of an HTTP request
+func (s *state) openAPIOperation(req *httpRequest, tag string) yamlMap {
+	op := yamlMap{
+		{"operationId", req.service.GoName + "_" + req.method.GoName},
+		{"summary", req.name},
+	}
+	if comments := strings.TrimSpace(string(req.method.Comments.Leading)); comments != "" {
+		var lines []string
+		for _, line := range strings.Split(comments, "\n") {
+			lines = append(lines, strings.TrimPrefix(line, " "))
+		}
+		op = append(op, yamlField{"description", strings.Join(lines, "\n")})
+	}
+	op = append(op, yamlField{"tags", []string{tag}})
+	if methodDeprecated(req.method) {
+		op = append(op, yamlField{"deprecated", true})
+	}
+
+	var params []any
+	for _, v := range req.pathVars {
+		params = append(params, yamlMap{
+			{"name", v[0]},
+			{"in", "path"},
+			{"required", true},
+			{"schema", map[string]any{"type": "string"}},
+			{"example", v[1]},
+		})
+	}
+	for _, field := range req.queryFields {
+		params = append(params, yamlMap{
+			{"name", field.Desc.JSONName()},
+			{"in", "query"},
+			{"schema", fieldSchema(field, map[protoreflect.FullName]bool{})},
+			{"example", yamlJSON(examples.FieldValue(field, s.exampleLimits))},
+		})
+	}
+	authHeaders, _ := s.authParams(req.authMode)
+	for _, header := range req.headers {
+		// The User-Agent is the client's, and credentials are security schemes
+		if header[0] == "User-Agent" || slices.Contains(authHeaders, header) {
+			continue
+		}
+		param := yamlMap{{"name", header[0]}, {"in", "header"}, {"schema", map[string]any{"type": "string"}}}
+		if !bruVarPattern.MatchString(header[1]) {
+			param = append(param, yamlField{"example", header[1]})
+		}
+		params = append(params, param)
+	}
+	if len(params) > 0 {
+		op = append(op, yamlField{"parameters", params})
+	}
+
+	if len(req.bodyFields) > 0 {
+		op = append(op, yamlField{"requestBody", yamlMap{
+			{"required", true},
+			{"content", yamlMap{{"application/json", yamlMap{
+				{"schema", openAPIBodySchema(req)},
+				{"example", yamlJSON(req.body)},
+			}}}},
+		}})
+	}
+
+	response := yamlMap{{"description", "A successful response."}}
+	if schema := openAPIResponseSchema(req); schema != nil {
+		response = append(response, yamlField{"content", yamlMap{{"application/json", yamlMap{{"schema", schema}}}}})
+	}
+	op = append(op, yamlField{"responses", yamlMap{{"200", response}}})
+
+	switch req.authMode {
+	case "bearer":
+		op = append(op, yamlField{"security", []any{yamlMap{{openAPIBearerScheme, []string{}}}}})
+	case "apikey":
+		op = append(op, yamlField{"security", []any{yamlMap{{openAPIKeyScheme, []string{}}}}})
+	}
+	return op
+}
+
+// openAPIBodySchema returns the schema of the body of an HTTP request: the
+// fields left out of the path with body "*", else the body field
+func openAPIBodySchema(req *httpRequest) map[string]any {
+	if req.rule.Body != "*" {
+		return fieldSchema(req.bodyFields[0], map[protoreflect.FullName]bool{})
+	}
+	schema := messageSchema(req.method.Input, map[protoreflect.FullName]bool{})
+	if len(req.bodyFields) == len(req.method.Input.Fields) {
+		return schema
+	}
+	allProperties, _ := schema["properties"].(map[string]any)
+	properties := map[string]any{}
+	for _, field := range req.bodyFields {
+		properties[field.Desc.JSONName()] = allProperties[field.Desc.JSONName()]
+	}
+	schema["properties"] = properties
+	if required, ok := schema["required"].([]string); ok {
+		required = slices.DeleteFunc(required, func(name string) bool { return properties[name] == nil })
+		if len(required) > 0 {
+			schema["required"] = required
+		} else {
+			delete(schema, "required")
+		}
+	}
+	return schema
+}
+
+// openAPIResponseSchema returns the schema of the response of an HTTP
+// request, the response_body field of the output if the rule has one, or nil
+// for google.protobuf.Empty
+func openAPIResponseSchema(req *httpRequest) map[string]any {
+	output := req.method.Output
+	if output.Desc.FullName() == "google.protobuf.Empty" {
+		return nil
+	}
+	if name := req.rule.GetResponseBody(); name != "" {
+		for _, field := range output.Fields {
+			if string(field.Desc.Name()) == name {
+				return fieldSchema(field, map[protoreflect.FullName]bool{})
+			}
+		}
+	}
+	return messageSchema(output, map[protoreflect.FullName]bool{})
+}
+
+// openAPIPathVarPattern matches the variables of a request path, {{name}}
+var openAPIPathVarPattern = regexp.MustCompile(`\{\{([^{}]+)\}\}`)
+
+// openAPIPath returns a request path with variables as OpenAPI path
+// templates, /v1/users/{{user_id}} -> /v1/users/{user_id}
+func openAPIPath(urlPath string) string {
+	return openAPIPathVarPattern.ReplaceAllString(urlPath, "{$1}")
+}
+
+// writeYAML writes the block YAML of a value at an indentation. Maps from
+// JSON schemas are written with their keys sorted.
+func writeYAML(b *strings.Builder, indent int, v any) {
+	pad := strings.Repeat(" ", indent)
+	switch v := v.(type) {
+	case yamlMap:
+		for _, field := range v {
+			b.WriteString(pad + yamlScalar(field.key) + ":")
+			writeYAMLValue(b, indent, field.value)
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			b.WriteString(pad + yamlScalar(key) + ":")
+			writeYAMLValue(b, indent, v[key])
+		}
+	case []any:
+		for _, item := range v {
+			writeYAMLItem(b, indent, item)
+		}
+	case []string:
+		for _, item := range v {
+			writeYAMLItem(b, indent, item)
+		}
+	}
+}
+
+// writeYAMLValue writes the value of a key, on the same line for scalars and
+// empty collections, else as an indented block
+func writeYAMLValue(b *strings.Builder, indent int, v any) {
+	switch value := v.(type) {
+	case yamlMap, map[string]any:
+		if yamlEmpty(value) {
+			b.WriteString(" {}\n")
+			return
+		}
+		b.WriteString("\n")
+		writeYAML(b, indent+2, value)
+	case []any, []string:
+		// Sequences are not indented under their key
+		if yamlEmpty(value) {
+			b.WriteString(" []\n")
+			return
+		}
+		b.WriteString("\n")
+		writeYAML(b, indent, value)
+	case yamlJSON:
+		b.WriteString(" " + strings.ReplaceAll(string(value), "\n", "\n"+strings.Repeat(" ", indent+2)) + "\n")
+	case string:
+		if strings.Contains(value, "\n") && !strings.HasPrefix(value, " ") {
+			b.WriteString(" |-\n")
+			for _, line := range strings.Split(value, "\n") {
+				if line != "" {
+					b.WriteString(strings.Repeat(" ", indent+2) + line)
+				}
+				b.WriteString("\n")
+			}
+			return
+		}
+		b.WriteString(" " + yamlScalar(value) + "\n")
+	default:
+		b.WriteString(" " + yamlScalar(value) + "\n")
+	}
+}
+
+// writeYAMLItem writes an item of a sequence, with the first line of a map on
+// the line of its dash
+func writeYAMLItem(b *strings.Builder, indent int, item any) {
+	switch item.(type) {
+	case yamlMap, map[string]any:
+		if !yamlEmpty(item) {
+			var block strings.Builder
+			writeYAML(&block, indent+2, item)
+			b.WriteString(strings.Repeat(" ", indent) + "- " + block.String()[indent+2:])
+			return
+		}
+	}
+	b.WriteString(strings.Repeat(" ", indent) + "-")
+	writeYAMLValue(b, indent, item)
+}
+
+// yamlEmpty reports whether a collection has no entries
+func yamlEmpty(v any) bool {
+	switch v := v.(type) {
+	case yamlMap:
+		return len(v) == 0
+	case map[string]any:
+		return len(v) == 0
+	case []any:
+		return len(v) == 0
+	case []string:
+		return len(v) == 0
+	}
+	return false
+}
+
+// yamlPlainPattern matches strings that can be written unquoted
+var yamlPlainPattern = regexp.MustCompile(`^[A-Za-z_/][A-Za-z0-9_ ./{}()-]*$`)
+
+// yamlScalar returns a scalar in YAML, quoting strings that would otherwise
+// read as another type or break the syntax
+func yamlScalar(v any) string {
+	switch v := v.(type) {
+	case string:
+		switch strings.ToLower(v) {
+		case "true", "false", "yes", "no", "on", "off", "y", "n", "null":
+			return strconv.Quote(v)
+		}
+		if yamlPlainPattern.MatchString(v) && !strings.HasSuffix(v, " ") {
+			return v
+		}
+		return strconv.Quote(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	}
+	return `""`
+}
@@ -0,0 +1,67 @@
+package brunogen
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// testHTTPRequest returns the test request with GetUser exposed as GET
+// /v1/users
+func testHTTPRequest() *pluginpb.CodeGeneratorRequest {
+	req := testRequest()
+	method := req.ProtoFile[0].Service[0].Method[0]
+	method.Options = &descriptorpb.MethodOptions{}
+	proto.SetExtension(method.Options, annotations.E_Http, &annotations.HttpRule{
+		Pattern: &annotations.HttpRule_Get{Get: "/v1/users"},
+	})
+	return req
+}
+
+func TestOpenAPIServers(t *testing.T) {
+	tests := []struct {
+		name      string
+		parameter string
+		want      []string
+	}{
+		{
+			name:      "environment URLs",
+			parameter: "openapi=true,dev_url=https://api.dev.example.com/users-api",
+			want:      []string{`url: "https://api.dev.example.com/users-api"`, "/v1/users:"},
+		},
+		{
+			name:      "split base path",
+			parameter: "openapi=true,split_base_path=true,dev_url=https://api.dev.example.com/users-api",
+			want:      []string{`url: "https://api.dev.example.com/users-api"`, "/v1/users:"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := New(Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := testHTTPRequest()
+			req.Parameter = proto.String(tt.parameter)
+			resp, err := g.Run(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var spec string
+			for _, file := range resp.File {
+				if file.GetName() == "openapi.yaml" {
+					spec = file.GetContent()
+				}
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(spec, want) {
+					t.Errorf("openapi.yaml has no %q:\n%s", want, spec)
+				}
+			}
+		})
+	}
+}
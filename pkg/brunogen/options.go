@@ -0,0 +1,106 @@
+package brunogen
+
+import (
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Field numbers of the bruno.v1 custom options declared in proto/bruno/v1/options.proto
+const (
+	methodAuthOption     protowire.Number = 50100
+	methodHMACSignOption protowire.Number = 50101
+)
+
+// stringOption reads a string custom option from an options message. The
+// extensions have no generated Go types, so they are decoded from the unknown
+// fields; like protobuf, the last occurrence wins.
+func stringOption(opts protoreflect.ProtoMessage, num protowire.Number) (string, bool) {
+	if opts == nil {
+		return "", false
+	}
+
+	var value string
+	var found bool
+	b := opts.ProtoReflect().GetUnknown()
+	for len(b) > 0 {
+		fieldNum, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			break
+		}
+		b = b[n:]
+
+		if fieldNum == num && typ == protowire.BytesType {
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				break
+			}
+			value, found = string(v), true
+			b = b[n:]
+			continue
+		}
+
+		n = protowire.ConsumeFieldValue(fieldNum, typ, b)
+		if n < 0 {
+			break
+		}
+		b = b[n:]
+	}
+
+	return value, found
+}
+
+// boolOption reads a bool custom option from an options message
+func boolOption(opts protoreflect.ProtoMessage, num protowire.Number) bool {
+	if opts == nil {
+		return false
+	}
+
+	var value bool
+	b := opts.ProtoReflect().GetUnknown()
+	for len(b) > 0 {
+		fieldNum, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			break
+		}
+		b = b[n:]
+
+		if fieldNum == num && typ == protowire.VarintType {
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				break
+			}
+			value = protowire.DecodeBool(v)
+			b = b[n:]
+			continue
+		}
+
+		n = protowire.ConsumeFieldValue(fieldNum, typ, b)
+		if n < 0 {
+			break
+		}
+		b = b[n:]
+	}
+
+	return value
+}
+
+// methodAuthOverride returns the (bruno.v1.auth) option of a method, if set to a
+// supported value
+func methodAuthOverride(method *protogen.Method) string {
+	value, ok := stringOption(method.Desc.Options(), methodAuthOption)
+	if !ok {
+		return ""
+	}
+	switch value {
+	case "none", "inherit", "bearer", "apikey", "basic":
+		return value
+	}
+	return ""
+}
+
+// methodHMACSign reports whether requests for a method must be HMAC-signed,
+// either for all methods via hmac_sign=true or via the (bruno.v1.hmac_sign) option
+func (s *state) methodHMACSign(method *protogen.Method) bool {
+	return s.hmacSignAll || boolOption(method.Desc.Options(), methodHMACSignOption)
+}
@@ -0,0 +1,215 @@
+package brunogen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// Set sets a plugin option, like a protoc parameter. It can serve as the
+// ParamFunc of protogen.Options. profile options and the options of profiles,
+// like external.mode, are kept for Run.
+func (g *Generator) Set(name, value string) error {
+	if name == "profile" {
+		return g.addProfile(value)
+	}
+	if profileName, option, ok := strings.Cut(name, "."); ok {
+		g.profileOptions = append(g.profileOptions, profileOption{profileName, option, value})
+		return nil
+	}
+	if err := g.flags.Set(name, value); err != nil {
+		return err
+	}
+	g.params = append(g.params, name+"="+value)
+	return nil
+}
+
+// Generate generates the collections of the proto files of a plugin
+func (g *Generator) Generate(gen *protogen.Plugin) error {
+	if len(g.profiles) > 0 || len(g.profileOptions) > 0 {
+		return fmt.Errorf("profiles are generated by Run, one generation each")
+	}
+	if err := g.generate(gen); err != nil {
+		return err
+	}
+	g.state.reportSkipped(g.log)
+	return nil
+}
+
+// Response returns the response of a plugin the Generator ran on, with the
+// generated files normalized so regenerating a collection only shows real
+// changes in diffs, and rewrite_path, out_prefix, write_mode and dry_run
+// applied. With hashes=true, a hashes.json of the generated files is added.
+func (g *Generator) Response(gen *protogen.Plugin) (*pluginpb.CodeGeneratorResponse, error) {
+	s := g.state
+	if s == nil {
+		return nil, fmt.Errorf("the Generator has not generated the plugin files")
+	}
+	resp := gen.Response()
+	if err := s.applyTemplates(resp); err != nil {
+		return nil, err
+	}
+	if err := s.applyPathRewrites(resp); err != nil {
+		return nil, err
+	}
+	for _, file := range resp.File {
+		file.Name = proto.String(s.outPrefix + file.GetName())
+		file.Content = proto.String(normalizeContent(file.GetContent()))
+	}
+	generated := make(map[string]bool)
+	for _, file := range resp.File {
+		generated[file.GetName()] = true
+	}
+	var hashes []fileHash
+	if s.hashFiles {
+		hashes = contentHashes(resp)
+	}
+	if err := s.applyWriteMode(resp); err != nil {
+		return nil, err
+	}
+	if s.hashFiles && resp.Error == nil {
+		if s.reportChangedFiles {
+			if err := s.reportChanges(g.log, hashes); err != nil {
+				return nil, err
+			}
+		}
+		file, err := s.hashesResponseFile(hashes)
+		if err != nil {
+			return nil, err
+		}
+		resp.File = append(resp.File, file)
+	}
+	if s.verify {
+		if err := s.verifyOutput(resp, generated); err != nil {
+			return nil, err
+		}
+	}
+	if s.dryRun && resp.Error == nil {
+		if err := s.applyDryRun(resp); err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(resp.File, func(i, j int) bool {
+		return resp.File[i].GetName() < resp.File[j].GetName()
+	})
+	return resp, nil
+}
+
+// Run generates the collections of a code generator request, as protoc runs
+// the plugin. The parameter of the request adds to the options of the
+// Generator for this run only, so a Generator can run many requests; values
+// may contain commas when quoted or escaped, see splitParams. With profiles,
+// each profile is generated in turn and the files combined.
+func (g *Generator) Run(req *pluginpb.CodeGeneratorRequest) (*pluginpb.CodeGeneratorResponse, error) {
+	params, err := splitParams(req.GetParameter())
+	if err != nil {
+		return nil, err
+	}
+	c, err := g.clone()
+	if err != nil {
+		return nil, err
+	}
+	// protogen splits the parameter on every comma, so it only gets its own options
+	var protogenParams []string
+	for _, param := range params {
+		name, value, _ := strings.Cut(param, "=")
+		switch {
+		case name == "":
+		case protogenParam(name):
+			protogenParams = append(protogenParams, param)
+		default:
+			if err := c.Set(name, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(c.profiles) > 0 || len(c.profileOptions) > 0 {
+		return c.runProfiles(req, protogenParams)
+	}
+	return c.run(req, protogenParams)
+}
+
+// clone returns a new Generator with the options and profiles set so far
+func (g *Generator) clone() (*Generator, error) {
+	c, err := New(Options{Params: g.params, Version: g.version, Log: g.log})
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range g.profiles {
+		c.profiles = append(c.profiles, profile{name: p.name, options: slices.Clone(p.options)})
+	}
+	c.profileOptions = slices.Clone(g.profileOptions)
+	return c, nil
+}
+
+// run generates the collections of a code generator request with the
+// options of the Generator, passing protogen its own options
+func (g *Generator) run(req *pluginpb.CodeGeneratorRequest, protogenParams []string) (*pluginpb.CodeGeneratorResponse, error) {
+	req = proto.CloneOf(req)
+	req.Parameter = proto.String(strings.Join(protogenParams, ","))
+
+	gen, err := protogen.Options{}.New(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.Generate(gen); err != nil {
+		// Errors from the generator are reported by setting the error field
+		// in the CodeGeneratorResponse
+		gen.Error(err)
+	}
+	return g.Response(gen)
+}
+
+// outputPrefix cleans the out_prefix directory into a prefix of generated
+// paths, e.g. "bruno/collections/". protoc rejects paths leaving the output
+// directory, so such prefixes are reported up front.
+func outputPrefix(dir string) (string, error) {
+	if dir == "" {
+		return "", nil
+	}
+	cleaned := path.Clean(strings.ReplaceAll(dir, "\\", "/"))
+	if path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("out_prefix %q must be a directory inside the output directory", dir)
+	}
+	if cleaned == "." {
+		return "", nil
+	}
+	return cleaned + "/", nil
+}
+
+// normalizeContent gives a generated file LF line endings, no trailing
+// whitespace and exactly one trailing newline
+func normalizeContent(content string) string {
+	content = strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(content)
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	content = strings.TrimRight(strings.Join(lines, "\n"), "\n")
+	if content == "" {
+		return ""
+	}
+	return content + "\n"
+}
+
+// writeJSONFile writes a value as an indented JSON file, leaving <, > and &
+// unescaped since values hold variable references and URLs
+func writeJSONFile(gen *protogen.Plugin, filename string, v any) error {
+	var content bytes.Buffer
+	enc := json.NewEncoder(&content)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	gen.NewGeneratedFile(filename, "").Write(content.Bytes())
+	return nil
+}
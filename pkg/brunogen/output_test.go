@@ -0,0 +1,52 @@
+package brunogen
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestRunTwice(t *testing.T) {
+	g, err := New(Options{Params: []string{"request_order=name"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := func(parameter string) map[string]string {
+		req := testRequest()
+		req.Parameter = proto.String(parameter)
+		resp, err := g.Run(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Error != nil {
+			t.Fatal(resp.GetError())
+		}
+		files := make(map[string]string)
+		for _, file := range resp.File {
+			files[file.GetName()] = file.GetContent()
+		}
+		return files
+	}
+
+	first := run("grpc_metadata=x-a: 1,profile=a,a.mode=grpc")
+	second := run("grpc_metadata=x-a: 1,profile=a,a.mode=grpc")
+	if len(first) != len(second) {
+		t.Fatalf("second run generated %d files, want %d", len(second), len(first))
+	}
+	for name, content := range first {
+		if second[name] != content {
+			t.Errorf("%s: second run generated\n%s\nwant\n%s", name, second[name], content)
+		}
+	}
+	if got := strings.Count(second["a/UserService-gRPC/GetUser.bru"], "x-a: 1"); got != 1 {
+		t.Errorf("GetUser.bru has the metadata %d times, want 1", got)
+	}
+
+	// Options of a request do not carry into the next one
+	for name := range run("") {
+		if strings.HasPrefix(name, "a/") {
+			t.Fatalf("run without profiles generated %s", name)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package brunogen
+
+import (
+	"runtime"
+	"sync"
+)
+
+// queueRequest defers writing the content of a request file to writeRequests.
+// The file itself must already be created, so the order of the output does
+// not depend on scheduling.
+func (s *state) queueRequest(job func() error) {
+	s.requestJobs = append(s.requestJobs, job)
+}
+
+// writeRequests writes the content of the queued request files concurrently,
+// since large descriptor sets have thousands of them. Request writers only
+// read the run state, which is complete once all files are created. The
+// error of the first failing request, in creation order, is returned. With
+// debug=true they are written one by one, so the trace follows that order.
+func (s *state) writeRequests() error {
+	jobs := s.requestJobs
+	s.requestJobs = nil
+
+	workers := runtime.GOMAXPROCS(0)
+	if s.debugLog != nil {
+		workers = 1
+	}
+	errs := make([]error, len(jobs))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(workers, len(jobs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				errs[i] = jobs[i]()
+			}
+		}()
+	}
+	for i := range jobs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package brunogen
+
+import (
+	"fmt"
+	"strings"
+)
+
+// splitParams splits the parameter protoc passes to the plugin into its
+// name=value options. protoc joins options with commas, so a comma inside a
+// value is written as "\," or the whole value is put in double quotes, like
+// header="Accept: text/html, application/json". Within quotes, \" and \\ stand
+// for a quote and a backslash. Other backslashes are kept as written, so
+// regular expressions need no extra escaping.
+func splitParams(parameter string) ([]string, error) {
+	var params []string
+	var param strings.Builder
+	quoted, valueStart := false, false
+	for i := 0; i < len(parameter); i++ {
+		c := parameter[i]
+		atValue := valueStart
+		valueStart = false
+		switch {
+		case quoted && c == '\\' && i+1 < len(parameter) && (parameter[i+1] == '"' || parameter[i+1] == '\\'):
+			i++
+			param.WriteByte(parameter[i])
+		case quoted && c == '"':
+			quoted = false
+			if i+1 < len(parameter) && parameter[i+1] != ',' {
+				return nil, fmt.Errorf("option %q: text after the closing quote", param.String())
+			}
+		case quoted:
+			param.WriteByte(c)
+		case c == '"' && atValue:
+			quoted = true
+		case c == '\\' && i+1 < len(parameter) && parameter[i+1] == ',':
+			i++
+			param.WriteByte(',')
+		case c == ',':
+			params = append(params, param.String())
+			param.Reset()
+		default:
+			// The value starts after the first "=" of an option
+			valueStart = c == '=' && !strings.Contains(param.String(), "=")
+			param.WriteByte(c)
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("option %q: missing closing quote", param.String())
+	}
+	return append(params, param.String()), nil
+}
+
+// protogenParam reports whether protogen handles an option itself, like the
+// M mappings of Go import paths, rather than the Generator
+func protogenParam(name string) bool {
+	switch name {
+	case "module", "paths", "annotate_code", "default_api_level":
+		return true
+	}
+	return strings.HasPrefix(name, "M")
+}
+
+// wordList is a flag.Value of a space-separated list option, like
+// folder_order. Repeating the option appends to the list.
+type wordList struct {
+	words *string
+}
+
+// String implements flag.Value
+func (l wordList) String() string {
+	if l.words == nil {
+		return ""
+	}
+	return *l.words
+}
+
+// Set implements flag.Value
+func (l wordList) Set(value string) error {
+	if *l.words != "" && value != "" {
+		*l.words += " "
+	}
+	*l.words += value
+	return nil
+}
@@ -0,0 +1,126 @@
+package brunogen
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitParams(t *testing.T) {
+	tests := []struct {
+		name      string
+		parameter string
+		want      []string
+		wantErr   string
+	}{
+		{
+			name:      "empty",
+			parameter: "",
+			want:      []string{""},
+		},
+		{
+			name:      "comma separated options",
+			parameter: "mode=http,collection_name=My API",
+			want:      []string{"mode=http", "collection_name=My API"},
+		},
+		{
+			name:      "escaped comma",
+			parameter: `header=Accept: text/html\, application/json,mode=http`,
+			want:      []string{"header=Accept: text/html, application/json", "mode=http"},
+		},
+		{
+			name:      "quoted value",
+			parameter: `header="Accept: text/html, application/json",mode=http`,
+			want:      []string{"header=Accept: text/html, application/json", "mode=http"},
+		},
+		{
+			name:      "escaped quote and backslash in a quoted value",
+			parameter: `collection_name="Say \"hi\" \\ bye"`,
+			want:      []string{`collection_name=Say "hi" \ bye`},
+		},
+		{
+			name:      "other backslashes are kept",
+			parameter: `include_services=^example\.v1\.,exclude_methods="/Delete\w+$"`,
+			want:      []string{`include_services=^example\.v1\.`, `exclude_methods=/Delete\w+$`},
+		},
+		{
+			name:      "quotes only open at the start of a value",
+			parameter: `collection_name=My "API",mode=http`,
+			want:      []string{`collection_name=My "API"`, "mode=http"},
+		},
+		{
+			name:      "later equal signs are part of the value",
+			parameter: `rewrite_path=^a="b`,
+			want:      []string{`rewrite_path=^a="b`},
+		},
+		{
+			name:      "empty options are kept",
+			parameter: "mode=http,,",
+			want:      []string{"mode=http", "", ""},
+		},
+		{
+			name:      "missing closing quote",
+			parameter: `mode=http,header="X-Tenant: acme`,
+			wantErr:   `option "header=X-Tenant: acme": missing closing quote`,
+		},
+		{
+			name:      "text after the closing quote",
+			parameter: `collection_name="My API"s,mode=http`,
+			wantErr:   `option "collection_name=My API": text after the closing quote`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitParams(tt.parameter)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("splitParams() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("splitParams() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitParams() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProtogenParam(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "module", want: true},
+		{name: "paths", want: true},
+		{name: "annotate_code", want: true},
+		{name: "default_api_level", want: true},
+		{name: "Mexample/v1/user.proto", want: true},
+		{name: "mode", want: false},
+		{name: "max_retries", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := protogenParam(tt.name); got != tt.want {
+				t.Errorf("protogenParam(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWordList(t *testing.T) {
+	var words string
+	list := wordList{&words}
+	for _, value := range []string{"Auth", "", "UserService AdminService"} {
+		if err := list.Set(value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := list.String(), "Auth UserService AdminService"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if got := (wordList{}).String(); got != "" {
+		t.Errorf("String() of an unset list = %q, want empty", got)
+	}
+}
@@ -0,0 +1,14 @@
+package brunogen
+
+// generatePathVars writes the vars:pre-request block holding the example values
+// of the path parameters
+func generatePathVars(w *bruWriter, vars [][2]string) {
+	if len(vars) == 0 {
+		return
+	}
+	w.open("vars:pre-request")
+	for _, v := range vars {
+		w.entry(v[0], v[1])
+	}
+	w.close()
+}
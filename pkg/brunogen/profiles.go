@@ -0,0 +1,104 @@
+package brunogen
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// profile is a generation of its own in a run, such as an external collection
+// with only HTTP requests next to an internal one with everything. It has the
+// options of the Generator, overridden by its own.
+type profile struct {
+	name    string
+	options [][2]string
+}
+
+// profileOption is an option of a profile, given as profile.option=value
+type profileOption struct {
+	profile, name, value string
+}
+
+// addProfile declares a profile, from a profile=name option
+func (g *Generator) addProfile(name string) error {
+	if name == "" || strings.ContainsFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-')
+	}) {
+		return fmt.Errorf("profile %q: names may only contain letters, digits, _ and -", name)
+	}
+	if slices.ContainsFunc(g.profiles, func(p profile) bool { return p.name == name }) {
+		return fmt.Errorf("profile %q is declared twice", name)
+	}
+	g.profiles = append(g.profiles, profile{name: name})
+	return nil
+}
+
+// assignProfileOptions gives the profile options to their profiles, once all
+// of them are declared
+func (g *Generator) assignProfileOptions() error {
+	for _, option := range g.profileOptions {
+		i := slices.IndexFunc(g.profiles, func(p profile) bool { return p.name == option.profile })
+		if i < 0 {
+			return fmt.Errorf("option %s.%s: no profile %q, declare it with profile=%s", option.profile, option.name, option.profile, option.profile)
+		}
+		g.profiles[i].options = append(g.profiles[i].options, [2]string{option.name, option.value})
+	}
+	g.profileOptions = nil
+	return nil
+}
+
+// runProfiles generates each profile of the Generator with a new Generator
+// and combines their files. Profiles are written to a directory named after
+// them under out_prefix, unless they set their own out_prefix.
+func (g *Generator) runProfiles(req *pluginpb.CodeGeneratorRequest, protogenParams []string) (*pluginpb.CodeGeneratorResponse, error) {
+	if err := g.assignProfileOptions(); err != nil {
+		return nil, err
+	}
+	resp := &pluginpb.CodeGeneratorResponse{}
+	owners := make(map[string]string)
+	for _, p := range g.profiles {
+		pg, err := New(Options{Params: g.params, Version: g.version, Log: g.log})
+		if err != nil {
+			return nil, err
+		}
+		ownPrefix := false
+		for _, option := range p.options {
+			if err := pg.Set(option[0], option[1]); err != nil {
+				return nil, fmt.Errorf("profile %s: %w", p.name, err)
+			}
+			ownPrefix = ownPrefix || option[0] == "out_prefix"
+		}
+		if !ownPrefix {
+			if err := pg.Set("out_prefix", path.Join(pg.flags.Lookup("out_prefix").Value.String(), p.name)); err != nil {
+				return nil, err
+			}
+		}
+
+		profileResp, err := pg.run(req, protogenParams)
+		if err != nil {
+			return nil, fmt.Errorf("profile %s: %w", p.name, err)
+		}
+		if profileResp.Error != nil {
+			profileResp.Error = proto.String("profile " + p.name + ": " + profileResp.GetError())
+			return profileResp, nil
+		}
+		for _, file := range profileResp.File {
+			if owner, ok := owners[file.GetName()]; ok {
+				return nil, fmt.Errorf("profiles %s and %s both generate %s, give them different out_prefix options", owner, p.name, file.GetName())
+			}
+			owners[file.GetName()] = p.name
+		}
+		resp.SupportedFeatures = profileResp.SupportedFeatures
+		resp.MinimumEdition, resp.MaximumEdition = profileResp.MinimumEdition, profileResp.MaximumEdition
+		resp.File = append(resp.File, profileResp.File...)
+	}
+	sort.SliceStable(resp.File, func(i, j int) bool {
+		return resp.File[i].GetName() < resp.File[j].GetName()
+	})
+	return resp, nil
+}
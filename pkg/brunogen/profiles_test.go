@@ -0,0 +1,95 @@
+package brunogen
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestRunProfiles(t *testing.T) {
+	tests := []struct {
+		name      string
+		parameter string
+		wantDirs  []string
+		wantErr   string
+	}{
+		{
+			name:      "profiles are written under their name",
+			parameter: "mode=http,profile=external,profile=internal,external.include_services=UserService,internal.mode=all",
+			wantDirs:  []string{"external", "internal"},
+		},
+		{
+			name:      "profiles set their own out_prefix",
+			parameter: `profile=a,profile=b,a.out_prefix=public,b.out_prefix="private/all"`,
+			wantDirs:  []string{"private", "public"},
+		},
+		{
+			name:      "invalid profile name",
+			parameter: "profile=my profile",
+			wantErr:   `profile "my profile": names may only contain letters, digits, _ and -`,
+		},
+		{
+			name:      "profile declared twice",
+			parameter: "profile=a,profile=a",
+			wantErr:   `profile "a" is declared twice`,
+		},
+		{
+			name:      "option of an undeclared profile",
+			parameter: "profile=a,b.mode=http",
+			wantErr:   `option b.mode: no profile "b", declare it with profile=b`,
+		},
+		{
+			name:      "invalid option of a profile",
+			parameter: "profile=a,a.no_such_option=1",
+			wantErr:   "profile a: no such flag -no_such_option",
+		},
+		{
+			name:      "profiles writing the same files",
+			parameter: "profile=a,profile=b,a.out_prefix=x,b.out_prefix=x",
+			wantErr:   "profiles a and b both generate x/AdminService-gRPC/Ban.bru, give them different out_prefix options",
+		},
+		{
+			name:      "malformed parameter",
+			parameter: `profile=a,a.collection_name="API`,
+			wantErr:   `option "a.collection_name=API": missing closing quote`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := New(Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := testRequest()
+			req.Parameter = proto.String(tt.parameter)
+			resp, err := g.Run(req)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Run() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if resp.Error != nil {
+				t.Fatalf("Run() response error = %s", resp.GetError())
+			}
+			dirs := make(map[string]bool)
+			for _, file := range resp.File {
+				dir, _, _ := strings.Cut(file.GetName(), "/")
+				dirs[dir] = true
+			}
+			var got []string
+			for dir := range dirs {
+				got = append(got, dir)
+			}
+			sort.Strings(got)
+			if strings.Join(got, " ") != strings.Join(tt.wantDirs, " ") {
+				t.Errorf("Run() wrote to %q, want %q", got, tt.wantDirs)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package brunogen
+
+import (
+	"strings"
+
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/envgen"
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/naming"
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// generateCollectionReadme writes a README.md summarizing the collection: its
+// services and requests, environments, auth setup and how to regenerate it.
+// It is derived from the descriptors, so it stays in sync with the protos.
+func (s *state) generateCollectionReadme(gen *protogen.Plugin, protoFiles []*protogen.File, prefix string, collectionName string, environments []envgen.Environment, mode generationMode) {
+	g := gen.NewGeneratedFile(prefix+"README.md", "")
+	g.P("# ", collectionName)
+	g.P("")
+	g.P("Bruno collection generated by protoc-gen-bruno. Do not edit it by hand: changes are overwritten when it is regenerated.")
+
+	g.P("")
+	g.P("## Services")
+	for _, f := range protoFiles {
+		for _, service := range f.Services {
+			if s.collectionPrefix(f, service) != prefix {
+				continue
+			}
+			g.P("")
+			g.P("### ", naming.Display(service.GoName))
+			if comment := firstSentence(strings.TrimSpace(string(service.Comments.Leading))); comment != "" {
+				g.P("")
+				g.P(comment)
+			}
+			g.P("")
+			g.P("| Request | Call |")
+			g.P("| --- | --- |")
+			for _, method := range service.Methods {
+				if mode.http() && s.hasHTTPRule(method) {
+					_, httpMethod, path, _, _ := s.methodHTTPRule(method)
+					g.P("| ", s.requestName(method, httpMethod), " | `", strings.ToUpper(httpMethod), " ", path, "` |")
+					continue
+				}
+				if mode.grpc() {
+					g.P("| ", s.requestName(method, "grpc"), " | `gRPC ", service.Desc.FullName(), "/", method.Desc.Name(), "` |")
+				}
+			}
+		}
+	}
+
+	if len(environments) > 0 {
+		g.P("")
+		g.P("## Environments")
+		g.P("")
+		g.P("| Environment | HTTP | gRPC |")
+		g.P("| --- | --- | --- |")
+		for _, env := range environments {
+			g.P("| ", env.Name, " | ", env.HTTPURL+env.BasePath, " | ", env.GRPCURL, " |")
+		}
+	}
+
+	g.P("")
+	g.P("## Setup")
+	g.P("")
+	steps := []string{"Open this folder in Bruno and select an environment."}
+	if s.secretsMode == secretsDotenv {
+		steps = append(steps, "Copy `.env.example` to `.env` and fill in the credentials.")
+	}
+	if len(environments) > 0 {
+		var names []string
+		seen := make(map[string]bool)
+		for _, v := range s.applySecretsMode(s.environmentVars(environments[0], mode)) {
+			if (v.secret || v.credential) && !seen[v.name] {
+				seen[v.name] = true
+				names = append(names, "`"+v.name+"`")
+			}
+		}
+		if len(names) > 0 {
+			steps = append(steps, "Set the credentials of the environment: "+strings.Join(names, ", ")+".")
+		}
+	}
+	if s.loginPath != "" && mode.http() {
+		steps = append(steps, "Send `"+s.fileCase("Auth")+"/"+s.fileCase("Login")+"` to store the token used by the other requests.")
+	}
+	switch {
+	case len(s.envAuthModes) > 0:
+		steps = append(steps, "Requests authenticate as configured for the selected environment.")
+	case s.collectionAuthMode != "":
+		steps = append(steps, "Requests inherit the collection's `"+s.collectionAuthMode+"` auth.")
+	case s.requestAuthMode != "":
+		steps = append(steps, "Requests send `"+s.requestAuthMode+"` auth.")
+	}
+	for i, step := range steps {
+		g.P(i+1, ". ", step)
+	}
+
+	g.P("")
+	g.P("## Regenerating")
+	g.P("")
+	g.P("Run protoc-gen-bruno again after changing the protos, with the same options:")
+	g.P("")
+	g.P("```sh")
+	command := []string{"protoc --bruno_out=<output_dir>"}
+	if param := gen.Request.GetParameter(); param != "" {
+		command = append(command, "  --bruno_opt="+param)
+	}
+	for _, f := range protoFiles {
+		// Imported files are picked up through include_imports
+		if f.Generate {
+			command = append(command, "  "+f.Desc.Path())
+		}
+	}
+	g.P(strings.Join(command, " \\\n"))
+	g.P("```")
+}
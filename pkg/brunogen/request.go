@@ -0,0 +1,275 @@
+package brunogen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/envgen"
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/examples"
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/grpcgen"
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/httpgen"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// httpRequest is the HTTP request of a method as the output formats other than
+// Bruno write it: what is sent, with example values, derived the same way as
+// the .bru requests
+type httpRequest struct {
+	file    *protogen.File
+	service *protogen.Service
+	method  *protogen.Method
+	name    string
+	// verb is the lower case HTTP method, like "get"
+	verb string
+	// path is the path of the HTTP rule, like /v1/users/{user_id}, and
+	// urlPath the same with variables, like /v1/users/{{user_id}}
+	path, urlPath string
+	// pathVars, query and headers are name and example value pairs
+	pathVars [][2]string
+	query    [][2]string
+	headers  [][2]string
+	// body is the example JSON body, if any
+	body string
+	// rule is the HTTP rule, with the fields it sends in the query and the
+	// body, and authMode the auth whose credentials are added to the request
+	rule                    *annotations.HttpRule
+	queryFields, bodyFields []*protogen.Field
+	authMode                string
+	// problem tells what is wrong with an invalid HTTP rule a placeholder
+	// request stands in for
+	problem string
+}
+
+// newHTTPRequest returns the HTTP request of a method, or nil when the method
+// gets none, which is recorded like for .bru requests
+func (s *state) newHTTPRequest(file *protogen.File, service *protogen.Service, method *protogen.Method) (*httpRequest, error) {
+	httpRule, httpMethod, path, problem, ok := s.methodHTTPRule(method)
+	if !ok {
+		s.skipMethod(method, "no google.api.http annotation")
+		return nil, nil
+	}
+	if problem != "" {
+		switch s.invalidHTTPRules {
+		case invalidRulesFail:
+			return nil, fmt.Errorf("%s: %s", grpcgen.RPCName(method), problem)
+		case invalidRulesSkip:
+			s.skipMethod(method, problem)
+			return nil, nil
+		}
+	}
+
+	queryFields, bodyFields := s.classifyFields(method, httpRule, httpMethod, extractPathParams(path))
+	urlPath, pathVars := httpgen.PathVariables(path)
+	req := &httpRequest{
+		file:     file,
+		service:  service,
+		method:   method,
+		name:     s.requestName(method, httpMethod),
+		verb:     httpMethod,
+		path:     path,
+		urlPath:  urlPath,
+		pathVars: pathVars,
+		query:    s.queryExamples(queryFields),
+		headers:  s.requestHeaders(service, method, httpMethod),
+		body:     s.requestBody(method, httpRule, bodyFields),
+		problem:  problem,
+
+		rule:        httpRule,
+		queryFields: queryFields,
+		bodyFields:  bodyFields,
+		authMode:    s.exportAuthMode(method),
+	}
+	authHeaders, authQuery := s.authParams(req.authMode)
+	req.headers = append(req.headers, authHeaders...)
+	req.query = append(req.query, authQuery...)
+	return req, nil
+}
+
+// httpRequests returns the HTTP requests of the methods of the proto files, in
+// declaration order
+func (s *state) httpRequests(protoFiles []*protogen.File) ([]*httpRequest, error) {
+	var requests []*httpRequest
+	for _, f := range protoFiles {
+		for _, service := range f.Services {
+			for _, method := range service.Methods {
+				req, err := s.newHTTPRequest(f, service, method)
+				if err != nil {
+					return nil, err
+				}
+				if req != nil {
+					requests = append(requests, req)
+				}
+			}
+		}
+	}
+	return requests, nil
+}
+
+// classifyFields splits the fields of a request message that are not path
+// parameters into query parameters and body fields, following the HTTP rule
+func (s *state) classifyFields(method *protogen.Method, httpRule *annotations.HttpRule, httpMethod string, pathParams []string) (queryFields []*protogen.Field, bodyFields []*protogen.Field) {
+	for _, field := range method.Input.Fields {
+		fieldName := string(field.Desc.Name())
+
+		// Skip path parameters
+		if isPathParam(fieldName, pathParams) {
+			s.tracef("method %s: field %s in the path", grpcgen.RPCName(method), fieldName)
+			continue
+		}
+
+		// For GET/DELETE, all non-path fields become query params
+		if httpMethod == "get" || httpMethod == "delete" {
+			s.tracef("method %s: field %s in the query, %s has no body", grpcgen.RPCName(method), fieldName, strings.ToUpper(httpMethod))
+			queryFields = append(queryFields, field)
+		} else {
+			// For POST/PUT/PATCH, check the body field
+			bodyFieldName := httpRule.Body
+			if bodyFieldName == "*" {
+				// All non-path fields go in body
+				s.tracef("method %s: field %s in the body, body is \"*\"", grpcgen.RPCName(method), fieldName)
+				bodyFields = append(bodyFields, field)
+			} else if bodyFieldName == fieldName {
+				// This specific field goes in body
+				s.tracef("method %s: field %s is the body", grpcgen.RPCName(method), fieldName)
+				bodyFields = append(bodyFields, field)
+			} else if bodyFieldName == "" {
+				// No body specified, treat like GET (query params)
+				s.tracef("method %s: field %s in the query, google.api.http has no body", grpcgen.RPCName(method), fieldName)
+				queryFields = append(queryFields, field)
+			} else {
+				// Other fields become query params
+				s.tracef("method %s: field %s in the query, body is %q", grpcgen.RPCName(method), fieldName, bodyFieldName)
+				queryFields = append(queryFields, field)
+			}
+		}
+	}
+	return queryFields, bodyFields
+}
+
+// queryExamples returns the query parameters of fields with example values
+func (s *state) queryExamples(queryFields []*protogen.Field) [][2]string {
+	var query [][2]string
+	for _, field := range queryFields {
+		// Remove quotes from string values for query params
+		query = append(query, [2]string{field.Desc.JSONName(), strings.Trim(examples.FieldValue(field, s.exampleLimits), `"`)})
+	}
+	return query
+}
+
+// requestHeaders returns the headers the enabled features add to the HTTP
+// request of a method, besides auth
+func (s *state) requestHeaders(service *protogen.Service, method *protogen.Method, httpMethod string) [][2]string {
+	var headers [][2]string
+	if s.userAgent != "" && !s.customHeaders.has("User-Agent") {
+		headers = append(headers, [2]string{"User-Agent", s.userAgent})
+	}
+	headers = append(headers, s.customHeaders...)
+	headers = append(headers, s.metadataHeaders()...)
+	if header, ok := s.etagHeader(service, method); ok {
+		headers = append(headers, header)
+	}
+	if s.idempotencyKey && (httpMethod == "post" || httpMethod == "put") {
+		headers = append(headers, [2]string{s.idempotencyHeader, s.varRef("idempotency_key")})
+	}
+	return headers
+}
+
+// requestBody returns the example JSON body of an HTTP request, or "" when it
+// has none
+func (s *state) requestBody(method *protogen.Method, httpRule *annotations.HttpRule, bodyFields []*protogen.Field) string {
+	if len(bodyFields) == 0 {
+		return ""
+	}
+	if httpRule.Body == "*" {
+		// All fields in body
+		return s.exampleJSON(method, method.Input)
+	}
+	// Specific field in body
+	return s.exampleJSON(method, bodyFields[0].Message)
+}
+
+// exportAuthMode returns the auth of the HTTP request of a method outside of
+// Bruno, such as in curl commands: bearer, apikey, or another mode sent
+// without credentials
+func (s *state) exportAuthMode(method *protogen.Method) string {
+	if authOverride := methodAuthOverride(method); authOverride != "" {
+		return authOverride
+	}
+	if s.openAPISecurity && s.methodOpenAPIAuth(method) != nil {
+		return ""
+	}
+	if s.collectionAuthMode == "bearer" && !s.inheritRequestAuth {
+		return "bearer"
+	}
+	return s.requestAuthMode
+}
+
+// authParams returns the headers and query parameters carrying the
+// credentials of an auth mode, with variable references
+func (s *state) authParams(authMode string) (headers [][2]string, query [][2]string) {
+	switch authMode {
+	case "bearer":
+		headers = append(headers, [2]string{"Authorization", "Bearer {{" + s.bearerTokenVar() + "}}"})
+	case "apikey":
+		if s.apiKeyPlacement == "queryparams" {
+			query = append(query, [2]string{s.apiKeyName, s.varRef("api_key")})
+		} else {
+			headers = append(headers, [2]string{s.apiKeyName, s.varRef("api_key")})
+		}
+	}
+	return headers, query
+}
+
+// requestURL returns the URL of an HTTP request with variable references,
+// without its query
+func (s *state) requestURL(r *httpRequest) string {
+	return s.baseURLRef() + r.urlPath
+}
+
+// requestVariables returns the variables the HTTP requests reference, with
+// default values, in order of first use: the URL of the first environment,
+// the example path parameters, and empty values for the rest, like
+// credentials. Variables read from the process environment are left out.
+func (s *state) requestVariables(requests []*httpRequest, environments []envgen.Environment) [][2]string {
+	values := map[string]string{s.varName("base_url"): s.curlBaseURL}
+	if len(environments) > 0 {
+		values[s.varName("base_url")] = environments[0].HTTPURL
+		values[s.varName("base_path")] = environments[0].BasePath
+	}
+	var texts []string
+	for _, req := range requests {
+		for _, v := range req.pathVars {
+			if _, ok := values[v[0]]; !ok {
+				values[v[0]] = v[1]
+			}
+		}
+		texts = append(texts, s.requestURL(req))
+		for _, param := range req.query {
+			texts = append(texts, param[1])
+		}
+		for _, header := range req.headers {
+			texts = append(texts, header[1])
+		}
+	}
+	return referencedVariables(texts, values)
+}
+
+// referencedVariables returns the variables referenced in texts with their
+// values, empty when missing, in order of first use. Variables read from the
+// process environment are left out.
+func referencedVariables(texts []string, values map[string]string) [][2]string {
+	var vars [][2]string
+	seen := make(map[string]bool)
+	for _, text := range texts {
+		for _, match := range bruVarPattern.FindAllStringSubmatch(text, -1) {
+			name := strings.TrimSpace(match[1])
+			if seen[name] || strings.HasPrefix(name, "process.env.") {
+				continue
+			}
+			seen[name] = true
+			vars = append(vars, [2]string{name, values[name]})
+		}
+	}
+	return vars
+}
@@ -0,0 +1,107 @@
+package brunogen
+
+import (
+	"strings"
+
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/naming"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+)
+
+// resourceDescriptors indexes the google.api.resource descriptors declared on
+// messages and with google.api.resource_definition, by resource type
+func resourceDescriptors(files []*protogen.File) map[string]*annotations.ResourceDescriptor {
+	descriptors := make(map[string]*annotations.ResourceDescriptor)
+	var walk func(messages []*protogen.Message)
+	walk = func(messages []*protogen.Message) {
+		for _, msg := range messages {
+			if resource := messageResource(msg); resource != nil {
+				descriptors[resource.GetType()] = resource
+			}
+			walk(msg.Messages)
+		}
+	}
+	for _, f := range files {
+		if opts := f.Desc.Options(); proto.HasExtension(opts, annotations.E_ResourceDefinition) {
+			for _, resource := range proto.GetExtension(opts, annotations.E_ResourceDefinition).([]*annotations.ResourceDescriptor) {
+				descriptors[resource.GetType()] = resource
+			}
+		}
+		walk(f.Messages)
+	}
+	return descriptors
+}
+
+// messageResource returns the google.api.resource descriptor of a message, or
+// nil when it is not a resource
+func messageResource(msg *protogen.Message) *annotations.ResourceDescriptor {
+	if msg == nil {
+		return nil
+	}
+	opts := msg.Desc.Options()
+	if !proto.HasExtension(opts, annotations.E_Resource) {
+		return nil
+	}
+	resource := proto.GetExtension(opts, annotations.E_Resource).(*annotations.ResourceDescriptor)
+	if resource.GetType() == "" {
+		return nil
+	}
+	return resource
+}
+
+// methodResourceType returns the type of the resource a method operates on,
+// following AIP conventions: the resource referenced by its name field or, for
+// collection methods, the child type of its parent field, else the resource
+// message it takes or returns, directly or in a repeated field of a List
+// response. It returns "" when the method has no resource information.
+func methodResourceType(method *protogen.Method) string {
+	for _, field := range method.Input.Fields {
+		opts := field.Desc.Options()
+		if !proto.HasExtension(opts, annotations.E_ResourceReference) {
+			continue
+		}
+		ref := proto.GetExtension(opts, annotations.E_ResourceReference).(*annotations.ResourceReference)
+		switch {
+		case field.Desc.Name() == "name" && ref.GetType() != "" && ref.GetType() != "*":
+			return ref.GetType()
+		case field.Desc.Name() == "parent" && ref.GetChildType() != "":
+			return ref.GetChildType()
+		}
+	}
+	for _, field := range method.Input.Fields {
+		if resource := messageResource(field.Message); resource != nil && !field.Desc.IsList() {
+			return resource.GetType()
+		}
+	}
+	if resource := messageResource(method.Output); resource != nil {
+		return resource.GetType()
+	}
+	for _, field := range method.Output.Fields {
+		if resource := messageResource(field.Message); resource != nil && field.Desc.IsList() {
+			return resource.GetType()
+		}
+	}
+	return ""
+}
+
+// newResourceFolder returns the folder of a resource type, named after its
+// plural ("example.com/User" with plural "users" gives Users/). Types without
+// a known plural get an "s" appended to their name.
+func (s *state) newResourceFolder(resourceType string, descriptor *annotations.ResourceDescriptor) groupFolder {
+	name := resourceType[strings.LastIndex(resourceType, "/")+1:]
+	plural := name + "s"
+	if descriptor.GetPlural() != "" {
+		plural = strings.ToUpper(descriptor.GetPlural()[:1]) + descriptor.GetPlural()[1:]
+	}
+
+	docs := "Requests on `" + resourceType + "` resources."
+	if patterns := descriptor.GetPattern(); len(patterns) > 0 {
+		docs += "\n\nPatterns: `" + strings.Join(patterns, "`, `") + "`"
+	}
+	return groupFolder{
+		folder: naming.SanitizeFile(s.fileCase(plural)),
+		name:   naming.Display(plural),
+		docs:   docs,
+	}
+}
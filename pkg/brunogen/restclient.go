@@ -0,0 +1,74 @@
+package brunogen
+
+import (
+	"strings"
+
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/envgen"
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// generateRESTClientFiles writes the HTTP requests of each service to a .http
+// file for the VS Code REST Client extension. File variables at the top hold
+// the base URL of the first environment, the example path parameters and the
+// credentials, and the other environments are listed to switch base_url.
+func (s *state) generateRESTClientFiles(gen *protogen.Plugin, requests []*httpRequest, environments []envgen.Environment) {
+	names, groups := requestsByService(requests, func(req *httpRequest) string {
+		return s.collectionPrefix(req.file, req.service) + s.serviceFolder(req.service) + ".http"
+	})
+	for _, name := range names {
+		requests := groups[name]
+		g := gen.NewGeneratedFile(name, "")
+		g.P("# ", s.serviceDisplayName(requests[0].service))
+		if comment := firstSentence(strings.TrimSpace(string(requests[0].service.Comments.Leading))); comment != "" {
+			g.P("# ", comment)
+		}
+		if len(environments) > 1 {
+			g.P("#")
+			g.P("# Environments, for ", s.varName("base_url"), ":")
+			for _, env := range environments {
+				g.P("#   ", env.Name, ": ", env.HTTPURL)
+			}
+		}
+		g.P()
+		for _, v := range s.requestVariables(requests, environments) {
+			g.P("@", v[0], " = ", v[1])
+		}
+
+		for _, req := range requests {
+			g.P()
+			g.P("### ", req.name)
+			g.P("# @name ", req.method.GoName)
+			if req.problem != "" {
+				g.P("# Warning: the google.api.http rule of this method is invalid: ", req.problem)
+			}
+			g.P(strings.ToUpper(req.verb), " ", restClientVars(s.requestURL(req)))
+			for i, param := range req.query {
+				sep := "&"
+				if i == 0 {
+					sep = "?"
+				}
+				g.P("    ", sep, param[0], "=", restClientVars(param[1]))
+			}
+			for _, header := range req.headers {
+				g.P(header[0], ": ", restClientVars(header[1]))
+			}
+			if req.body != "" {
+				g.P("Content-Type: application/json")
+				g.P()
+				g.P(req.body)
+			}
+		}
+	}
+}
+
+// restClientVars rewrites references to process environment variables, which
+// Bruno reads from .env, as REST Client system variables
+func restClientVars(value string) string {
+	return bruVarPattern.ReplaceAllStringFunc(value, func(ref string) string {
+		name := strings.TrimSpace(ref[2 : len(ref)-2])
+		if env, ok := strings.CutPrefix(name, "process.env."); ok {
+			return "{{$processEnv " + env + "}}"
+		}
+		return ref
+	})
+}
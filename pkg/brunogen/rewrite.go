@@ -0,0 +1,73 @@
+package brunogen
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// rewriteRule replaces the matches of a pattern in generated file paths
+type rewriteRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// rewriteRuleList collects repeated rewrite_path options of the form
+// "pattern=>replacement", applied in order
+type rewriteRuleList []rewriteRule
+
+// String implements flag.Value
+func (l *rewriteRuleList) String() string {
+	var rules []string
+	for _, rule := range *l {
+		rules = append(rules, rule.pattern.String()+"=>"+rule.replacement)
+	}
+	return strings.Join(rules, " ")
+}
+
+// Set implements flag.Value, appending one rule per occurrence of the option
+func (l *rewriteRuleList) Set(value string) error {
+	pattern, replacement, ok := strings.Cut(value, "=>")
+	if !ok || pattern == "" {
+		return fmt.Errorf("invalid path rewrite %q, expected \"pattern=>replacement\"", value)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid path rewrite pattern %q: %v", pattern, err)
+	}
+	*l = append(*l, rewriteRule{pattern: re, replacement: replacement})
+	return nil
+}
+
+// rewritePath applies the rewrite_path rules to a generated file path.
+// Replacements may refer to groups of the pattern, like $1 or ${name}.
+func (s *state) rewritePath(name string) string {
+	for _, rule := range s.pathRewrites {
+		name = rule.pattern.ReplaceAllString(name, rule.replacement)
+	}
+	return name
+}
+
+// applyPathRewrites rewrites the paths of the generated files, reporting paths
+// that leave the output directory and files rewritten to the same path
+func (s *state) applyPathRewrites(resp *pluginpb.CodeGeneratorResponse) error {
+	if len(s.pathRewrites) == 0 {
+		return nil
+	}
+	sources := make(map[string]string)
+	for _, file := range resp.File {
+		name := path.Clean(s.rewritePath(file.GetName()))
+		if name == "." || path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("rewrite_path maps %s outside the output directory, to %q", file.GetName(), name)
+		}
+		if source, ok := sources[name]; ok {
+			return fmt.Errorf("rewrite_path maps both %s and %s to %s", source, file.GetName(), name)
+		}
+		sources[name] = file.GetName()
+		file.Name = &name
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package brunogen
+
+import (
+	"encoding/json"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// schemaTestScript returns a test validating the response body against a JSON
+// schema derived from the method's output message
+func schemaTestScript(method *protogen.Method) []string {
+	schema, err := json.MarshalIndent(messageSchema(method.Output, map[protoreflect.FullName]bool{}), "", "  ")
+	if err != nil {
+		return nil
+	}
+
+	schemaLines := strings.Split(string(schema), "\n")
+	schemaLines[0] = "const schema = " + schemaLines[0]
+	schemaLines[len(schemaLines)-1] += ";"
+
+	lines := []string{`const Ajv = require("ajv");`}
+	lines = append(lines, schemaLines...)
+	return append(lines,
+		"",
+		`test("response matches the `+string(method.Output.Desc.Name())+` schema", function () {`,
+		"  const validate = new Ajv({ allErrors: true }).compile(schema);",
+		"  const valid = validate(res.getBody());",
+		"  expect(valid, JSON.stringify(validate.errors)).to.be.true;",
+		"});",
+	)
+}
+
+// messageSchema returns the JSON schema of a message in its proto3 JSON form.
+// Fields marked REQUIRED by google.api.field_behavior must be present, since
+// proto3 JSON omits fields holding default values. Recursive messages are only
+// described as objects past their first occurrence.
+func messageSchema(msg *protogen.Message, seen map[protoreflect.FullName]bool) map[string]any {
+	if schema := wellKnownSchema(msg.Desc.FullName()); schema != nil {
+		return schema
+	}
+	if seen[msg.Desc.FullName()] {
+		return map[string]any{"type": "object"}
+	}
+	seen[msg.Desc.FullName()] = true
+	defer delete(seen, msg.Desc.FullName())
+
+	properties := map[string]any{}
+	var required []string
+	for _, field := range msg.Fields {
+		properties[field.Desc.JSONName()] = fieldSchema(field, seen)
+		if fieldRequired(field) {
+			required = append(required, field.Desc.JSONName())
+		}
+	}
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// fieldSchema returns the JSON schema of a field, including lists and maps
+func fieldSchema(field *protogen.Field, seen map[protoreflect.FullName]bool) map[string]any {
+	switch {
+	case field.Desc.IsMap():
+		return map[string]any{"type": "object", "additionalProperties": valueSchema(field.Message.Fields[1], seen)}
+	case field.Desc.IsList():
+		return map[string]any{"type": "array", "items": valueSchema(field, seen)}
+	default:
+		return valueSchema(field, seen)
+	}
+}
+
+// valueSchema returns the JSON schema of a single value of a field
+func valueSchema(field *protogen.Field, seen map[protoreflect.FullName]bool) map[string]any {
+	switch field.Desc.Kind() {
+	case protoreflect.StringKind, protoreflect.BytesKind:
+		return map[string]any{"type": "string"}
+	case protoreflect.BoolKind:
+		return map[string]any{"type": "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return map[string]any{"type": "integer"}
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		// NaN and Infinity are encoded as strings
+		return map[string]any{"type": []string{"number", "string"}}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		// 64-bit integers are encoded as strings, though numbers are accepted
+		return map[string]any{"type": []string{"string", "integer"}}
+	case protoreflect.EnumKind:
+		return map[string]any{"type": []string{"string", "integer"}}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return messageSchema(field.Message, seen)
+	default:
+		return map[string]any{}
+	}
+}
+
+// wellKnownSchema returns the schema of well-known types with a special JSON
+// representation, or nil for other messages
+func wellKnownSchema(name protoreflect.FullName) map[string]any {
+	switch name {
+	case "google.protobuf.Timestamp", "google.protobuf.Duration", "google.protobuf.FieldMask",
+		"google.protobuf.StringValue", "google.protobuf.BytesValue":
+		return map[string]any{"type": "string"}
+	case "google.protobuf.Struct", "google.protobuf.Any", "google.protobuf.Empty":
+		return map[string]any{"type": "object"}
+	case "google.protobuf.ListValue":
+		return map[string]any{"type": "array"}
+	case "google.protobuf.Value":
+		return map[string]any{}
+	case "google.protobuf.BoolValue":
+		return map[string]any{"type": "boolean"}
+	case "google.protobuf.Int32Value", "google.protobuf.UInt32Value":
+		return map[string]any{"type": "integer"}
+	case "google.protobuf.Int64Value", "google.protobuf.UInt64Value":
+		return map[string]any{"type": []string{"string", "integer"}}
+	case "google.protobuf.FloatValue", "google.protobuf.DoubleValue":
+		return map[string]any{"type": []string{"number", "string"}}
+	}
+	if strings.HasPrefix(string(name), "google.protobuf.") {
+		return map[string]any{}
+	}
+	return nil
+}
+
+// fieldRequired reports whether google.api.field_behavior marks a field as
+// REQUIRED, or its presence does, like features.field_presence = LEGACY_REQUIRED
+func fieldRequired(field *protogen.Field) bool {
+	if field.Desc.Cardinality() == protoreflect.Required {
+		return true
+	}
+	for _, behavior := range fieldBehaviors(field) {
+		if behavior == annotations.FieldBehavior_REQUIRED {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,205 @@
+package brunogen
+
+import (
+	"strconv"
+	"strings"
+)
+
+// generateScriptBlock writes a script block made of several feature snippets.
+// When there is more than one, each snippet gets its own block scope so their
+// local declarations cannot clash.
+func (s *state) generateScriptBlock(w *bruWriter, name string, snippets [][]string) {
+	// Empty blocks are still written to carry their insertion point
+	if len(snippets) == 0 && !s.insertionPoints {
+		return
+	}
+
+	w.open(name)
+	for i, snippet := range snippets {
+		if len(snippets) == 1 {
+			w.text(snippet...)
+			continue
+		}
+		if i > 0 {
+			w.text("")
+		}
+		w.text("{")
+		for _, line := range snippet {
+			w.text("  " + line)
+		}
+		w.text("}")
+	}
+	w.close()
+}
+
+// hmacSignHelper returns a pre-request script that signs the request with
+// HMAC-SHA256 over "METHOD\npath?query\nbody" and sends the hex signature in
+// the configured header
+func (s *state) hmacSignHelper() libraryHelper {
+	return libraryHelper{file: "auth", name: "signRequest", params: []string{"req", "bru"}, body: []string{
+		`const crypto = require("crypto");`,
+		`const url = new URL(bru.interpolate(req.getUrl()));`,
+		`const body = req.getBody() ? JSON.stringify(req.getBody()) : "";`,
+		`const payload = [req.getMethod().toUpperCase(), url.pathname + url.search, body].join("\n");`,
+		`const secret = ` + s.credentialScriptRef("hmac_secret") + `;`,
+		`const signature = crypto.createHmac("sha256", secret).update(payload).digest("hex");`,
+		`req.setHeader("` + s.hmacHeader + `", signature);`,
+	}}
+}
+
+// idempotencyKeyHelper returns a pre-request script that stores a fresh UUID in
+// the request variable referenced by the idempotency key header
+func (s *state) idempotencyKeyHelper() libraryHelper {
+	return libraryHelper{file: "ids", name: "setIdempotencyKey", params: []string{"bru"}, body: []string{
+		`bru.setVar("` + s.varName("idempotency_key") + `", require("crypto").randomUUID());`,
+	}}
+}
+
+// csrfTokenHelper returns a pre-request script that fetches the CSRF endpoint
+// and echoes the token in the CSRF header. The token is read from the
+// configured cookie when set, otherwise from the response header or a "token"
+// field in the response body.
+func (s *state) csrfTokenHelper() libraryHelper {
+	lines := []string{
+		`const axios = require("axios");`,
+		`const csrf = await axios.get(bru.interpolate("` + s.baseURLRef() + s.csrfEndpoint + `"));`,
+		`let token = csrf.headers["` + strings.ToLower(s.csrfHeader) + `"] || csrf.data?.token;`,
+	}
+	if s.csrfCookie != "" {
+		lines = append(lines,
+			`const cookie = (csrf.headers["set-cookie"] || []).map((c) => c.split(";")[0]).find((c) => c.startsWith("`+s.csrfCookie+`="));`,
+			`if (cookie) {`,
+			`  token = decodeURIComponent(cookie.slice("`+s.csrfCookie+`=".length));`,
+			`  req.setHeader("Cookie", cookie);`,
+			`}`,
+		)
+	}
+	lines = append(lines,
+		`if (token) {`,
+		`  req.setHeader("`+s.csrfHeader+`", token);`,
+		`}`,
+	)
+	return libraryHelper{file: "auth", name: "fetchCsrfToken", params: []string{"req", "bru"}, body: lines}
+}
+
+// retryHelper returns a post-response script that, in the collection runner,
+// re-sends a request rejected with 429 or RESOURCE_EXHAUSTED after waiting per
+// Retry-After, or with exponential backoff, up to max_retries times. Attempts
+// are counted per request, by name and path, and the count is cleared once the
+// request stops being retried.
+func (s *state) retryHelper() libraryHelper {
+	return libraryHelper{file: "retry", name: "retryRateLimited", params: []string{"req", "res", "bru"}, body: []string{
+		`const attemptsVar = "` + s.varName("retry_attempts") + `_" + (req.getName() + " " + req.getUrl().split("?")[0]).replace(/[^\w.-]/g, "_");`,
+		`const attempts = bru.getVar(attemptsVar) || 0;`,
+		`const rateLimited = res.getStatus() === 429 || res.getBody()?.code === 8;`,
+		`if (rateLimited && attempts < ` + strconv.Itoa(s.maxRetries) + `) {`,
+		`  const retryAfter = res.getHeader("retry-after");`,
+		`  let delay = 1000 * 2 ** attempts;`,
+		`  if (retryAfter) {`,
+		`    delay = isNaN(retryAfter) ? Date.parse(retryAfter) - Date.now() : Number(retryAfter) * 1000;`,
+		`  }`,
+		`  bru.setVar(attemptsVar, attempts + 1);`,
+		`  await bru.sleep(Math.max(delay, 0));`,
+		`  bru.setNextRequest(req.getName());`,
+		`} else if (attempts) {`,
+		`  bru.setVar(attemptsVar, 0);`,
+		`}`,
+	}}
+}
+
+// devJWTScript returns a script that, in the Local environment, signs an HS256
+// JWT with the configured claims and uses it as the bearer token. The token is
+// set in the environment, where the login request and token refresh keep it.
+func (s *state) devJWTScript() []string {
+	claims := []string{"iat: now", "exp: now + 3600"}
+	for _, pair := range strings.Fields(s.devJWTClaims) {
+		name, value, _ := strings.Cut(pair, "=")
+		claims = append(claims, strconv.Quote(name)+": "+strconv.Quote(value))
+	}
+
+	return []string{
+		`if (bru.getEnvName() === "Local") {`,
+		`  const crypto = require("crypto");`,
+		`  const encode = (data) => Buffer.from(JSON.stringify(data)).toString("base64url");`,
+		`  const now = Math.floor(Date.now() / 1000);`,
+		`  const claims = { ` + strings.Join(claims, ", ") + ` };`,
+		`  const unsigned = encode({ alg: "HS256", typ: "JWT" }) + "." + encode(claims);`,
+		`  const secret = ` + s.credentialScriptRef("jwt_secret") + `;`,
+		`  const signature = crypto.createHmac("sha256", secret).update(unsigned).digest("base64url");`,
+		`  bru.setEnvVar("` + s.bearerTokenVar() + `", unsigned + "." + signature);`,
+		`}`,
+	}
+}
+
+// idTokenScript returns a script that obtains a cloud identity token, from the
+// configured command or metadata URL, and uses it as the bearer token. Tokens
+// are reused for 50 minutes since identity tokens are valid for an hour, and
+// set in the environment like the other bearer tokens.
+func (s *state) idTokenScript() []string {
+	lines := []string{
+		`const idTokenExpiry = bru.getVar("` + s.varName("id_token_expiry") + `");`,
+		`if (!idTokenExpiry || Date.now() > idTokenExpiry) {`,
+	}
+	if s.idTokenCommand != "" {
+		lines = append(lines,
+			`  const { execSync } = require("child_process");`,
+			`  const token = execSync(`+strconv.Quote(s.idTokenCommand)+`, { encoding: "utf8" }).trim();`,
+		)
+	} else {
+		lines = append(lines,
+			`  const axios = require("axios");`,
+			`  const res = await axios.get(bru.interpolate(`+strconv.Quote(s.idTokenURL)+`), { headers: { "Metadata-Flavor": "Google" } });`,
+			`  const token = String(res.data).trim();`,
+		)
+	}
+	return append(lines,
+		`  bru.setEnvVar("`+s.bearerTokenVar()+`", token);`,
+		`  bru.setVar("`+s.varName("id_token_expiry")+`", Date.now() + 50 * 60 * 1000);`,
+		`}`,
+	)
+}
+
+// tokenRefreshScript returns a script that decodes the exp claim of the bearer
+// token and, when it expires within 30 seconds, exchanges the refresh token for
+// a new one. Requests without a token, such as the login request, are left alone.
+func (s *state) tokenRefreshScript() []string {
+	tokenVar := s.bearerTokenVar()
+	return []string{
+		`const currentToken = bru.getEnvVar("` + tokenVar + `");`,
+		`let tokenExpired = false;`,
+		`try {`,
+		`  const claims = JSON.parse(Buffer.from(currentToken.split(".")[1], "base64url").toString());`,
+		`  tokenExpired = !!claims.exp && claims.exp * 1000 < Date.now() + 30 * 1000;`,
+		`} catch (e) {`,
+		`  // Not a JWT; its expiry is unknown`,
+		`}`,
+		`if (currentToken && tokenExpired) {`,
+		`  const axios = require("axios");`,
+		`  const res = await axios.post(bru.interpolate("` + s.baseURLRef() + s.refreshPath + `"), {`,
+		`    ` + strconv.Quote(s.refreshTokenField) + `: bru.getEnvVar("` + s.varName("refresh_token") + `"),`,
+		`  });`,
+		`  const token = ` + fieldAccessor("res.data", s.loginTokenField) + `;`,
+		`  if (token) {`,
+		`    bru.setEnvVar("` + tokenVar + `", token);`,
+		`  }`,
+		`  const refreshToken = ` + fieldAccessor("res.data", s.refreshTokenField) + `;`,
+		`  if (refreshToken) {`,
+		`    bru.setEnvVar("` + s.varName("refresh_token") + `", refreshToken);`,
+		`  }`,
+		`}`,
+	}
+}
+
+// traceContextScript returns a script giving each request a fresh W3C
+// traceparent and, when configured, a correlation ID header
+func (s *state) traceContextScript() []string {
+	lines := []string{
+		`const traceId = require("crypto").randomBytes(16).toString("hex");`,
+		`const spanId = require("crypto").randomBytes(8).toString("hex");`,
+		`req.setHeader("traceparent", "00-" + traceId + "-" + spanId + "-01");`,
+	}
+	if s.correlationHeader != "" {
+		lines = append(lines, `req.setHeader("`+s.correlationHeader+`", require("crypto").randomUUID());`)
+	}
+	return lines
+}
@@ -0,0 +1,103 @@
+package brunogen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBearerTokenScriptsShareTheEnvironment(t *testing.T) {
+	tests := []struct {
+		name   string
+		params []string
+		token  string
+	}{
+		{name: "dev_jwt", params: []string{"dev_jwt=true"}, token: "token"},
+		{name: "id_token_command", params: []string{"id_token_command=gcloud auth print-identity-token"}, token: "token"},
+		{name: "collection auth", params: []string{"auth_mode=bearer", "dev_jwt=true"}, token: "bearer_token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := New(Options{Params: append([]string{"auth=bearer", "login_path=/v1/auth/login", "refresh_path=/v1/auth/refresh"}, tt.params...)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := g.Run(testRequest())
+			if err != nil {
+				t.Fatal(err)
+			}
+			files := make(map[string]string)
+			for _, file := range resp.File {
+				files[file.GetName()] = file.GetContent()
+			}
+			collection, token := files["collection.bru"], tt.token
+			for _, want := range []string{
+				`const currentToken = bru.getEnvVar("` + token + `");`,
+				`bru.setEnvVar("` + token + `", `,
+			} {
+				if !strings.Contains(collection, want) {
+					t.Errorf("collection.bru has no %q:\n%s", want, collection)
+				}
+			}
+			if strings.Contains(collection, `bru.setVar("`+token+`"`) {
+				t.Errorf("collection.bru keeps the token in a runtime variable:\n%s", collection)
+			}
+			if login := files["Auth/Login.bru"]; !strings.Contains(login, `bru.setEnvVar("`+token+`", token);`) {
+				t.Errorf("Login.bru does not store %s in the environment:\n%s", token, login)
+			}
+		})
+	}
+}
+
+func TestRetryHelperCountsPerRequest(t *testing.T) {
+	s := newState()
+	s.maxRetries = 3
+	s.varPrefix = "billing_"
+	body := strings.Join(s.retryHelper().body, "\n")
+	for _, want := range []string{
+		`const attemptsVar = "billing_retry_attempts_" + (req.getName() + " " + req.getUrl().split("?")[0])`,
+		`if (rateLimited && attempts < 3) {`,
+		"bru.setVar(attemptsVar, attempts + 1);",
+		"} else if (attempts) {\n  bru.setVar(attemptsVar, 0);\n}",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("retry script has no %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, `bru.getVar("billing_retry_attempts")`) {
+		t.Errorf("retry script shares one counter between requests:\n%s", body)
+	}
+}
+
+func TestGrpcurlScriptsFollowTheEnvironmentTLS(t *testing.T) {
+	tests := []struct {
+		name   string
+		params []string
+		flags  string
+	}{
+		{name: "local", flags: "${GRPCURL_FLAGS:--plaintext}"},
+		{name: "https", params: []string{"dev_url=https://api.dev.example.com"}, flags: "${GRPCURL_FLAGS:-}"},
+		{name: "grpc port 443", params: []string{"local_url=http://localhost:8080", "grpc_local_url=grpc.example.com:443"}, flags: "${GRPCURL_FLAGS:-}"},
+		{name: "mtls", params: []string{"dev_url=https://api.dev.example.com", "mtls=true"}, flags: "${GRPCURL_FLAGS:--cert certs/client.crt -key certs/client.key}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := New(Options{Params: append([]string{"format=scripts"}, tt.params...)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := g.Run(testRequest())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, file := range resp.File {
+				if file.GetName() == "scripts/UserService-gRPC/GetUser.sh" {
+					if want := "grpcurl " + tt.flags + " \\\n"; !strings.Contains(file.GetContent(), want) {
+						t.Errorf("GetUser.sh has no %q:\n%s", want, file.GetContent())
+					}
+					return
+				}
+			}
+			t.Fatal("no scripts/UserService-gRPC/GetUser.sh")
+		})
+	}
+}
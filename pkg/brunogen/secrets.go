@@ -0,0 +1,94 @@
+package brunogen
+
+import (
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// Supported secrets hygiene modes
+const (
+	secretsSecretVars = "secret-vars"
+	secretsDotenv     = "dotenv"
+)
+
+// fromDotenv reports whether a credential variable is read from the collection's
+// .env file. The bearer token stays an environment variable when the login
+// request or token refresh is generated, since their scripts store the token
+// at runtime.
+func (s *state) fromDotenv(name string) bool {
+	if s.secretsMode != secretsDotenv {
+		return false
+	}
+	return !((s.loginPath != "" || s.refreshPath != "") && (name == s.varName("token") || name == s.collectionTokenVar))
+}
+
+// dotenvName returns the process environment name of a credential variable
+func dotenvName(name string) string {
+	return strings.ToUpper(name)
+}
+
+// credentialRef returns the Bruno reference to a credential variable
+func (s *state) credentialRef(name string) string {
+	return s.rawCredentialRef(s.varName(name))
+}
+
+// rawCredentialRef returns the Bruno reference to a credential variable whose
+// name is used as given, without the configured prefix
+func (s *state) rawCredentialRef(name string) string {
+	if s.fromDotenv(name) {
+		return "{{process.env." + dotenvName(name) + "}}"
+	}
+	return "{{" + name + "}}"
+}
+
+// credentialScriptRef returns the script expression reading a credential variable
+func (s *state) credentialScriptRef(name string) string {
+	name = s.varName(name)
+	if s.fromDotenv(name) {
+		return `bru.getProcessEnv("` + dotenvName(name) + `")`
+	}
+	return `bru.getEnvVar("` + name + `")`
+}
+
+// applySecretsMode enforces the secrets mode on environment variables: with
+// secret-vars every credential becomes a secret, and with dotenv credentials are
+// removed from environments altogether
+func (s *state) applySecretsMode(vars []environmentVar) []environmentVar {
+	if s.secretsMode == "" {
+		return vars
+	}
+
+	result := vars[:0]
+	for _, v := range vars {
+		if v.credential {
+			if s.fromDotenv(v.name) {
+				continue
+			}
+			v.secret = true
+		}
+		result = append(result, v)
+	}
+	return result
+}
+
+// generateDotenvExample writes a .env.example listing the credentials the
+// collection reads from process.env; copy it to .env and fill in the values
+func (s *state) generateDotenvExample(gen *protogen.Plugin, prefix string, vars []environmentVar) {
+	var names []string
+	for _, v := range vars {
+		if v.credential && s.fromDotenv(v.name) {
+			names = append(names, dotenvName(v.name))
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+
+	g := gen.NewGeneratedFile(prefix+".env.example", "")
+	g.P("# Credentials used by this collection. Copy to .env and fill in the values;")
+	g.P("# never commit the .env file.")
+	for _, name := range names {
+		g.P(name, "=")
+	}
+}
@@ -0,0 +1,133 @@
+package brunogen
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/envgen"
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/grpcgen"
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// generateShellScripts writes a shell script per request to scripts/, in the
+// folders of the .bru files: a curl command per HTTP request and a grpcurl
+// command per RPC. Variables are read from the environment, like TOKEN=...
+// scripts/UserService/GetUser.sh, and default to the values of the first
+// environment and the examples. Extra arguments are passed to curl or grpcurl.
+func (s *state) generateShellScripts(gen *protogen.Plugin, protoFiles []*protogen.File, requests []*httpRequest, environments []envgen.Environment, mode generationMode) {
+	for _, req := range requests {
+		filename := s.collectionPrefix(req.file, req.service) + "scripts/" + s.methodFolder(req.service, req.method) + "/" + s.requestFileStem(req.method, req.verb) + ".sh"
+		g := gen.NewGeneratedFile(filename, "")
+		writeScriptHeader(g, req.name, req.method, s.requestVariables([]*httpRequest{req}, environments))
+		if req.problem != "" {
+			g.P("# Warning: the google.api.http rule of this method is invalid: ", req.problem)
+		}
+
+		target := s.requestURL(req)
+		for i, param := range req.query {
+			sep := "&"
+			if i == 0 {
+				sep = "?"
+			}
+			value := param[1]
+			if !bruVarPattern.MatchString(value) {
+				value = url.QueryEscape(value)
+			}
+			target += sep + param[0] + "=" + value
+		}
+		lines := []string{`curl -sS -X ` + strings.ToUpper(req.verb) + ` "` + shellVars(target) + `"`}
+		for _, header := range req.headers {
+			lines = append(lines, `  -H "`+header[0]+": "+shellVars(header[1])+`"`)
+		}
+		if req.body != "" {
+			lines = append(lines, `  -H "Content-Type: application/json"`, `  --data-binary @- "$@" <<'JSON'`)
+		} else {
+			lines[len(lines)-1] += ` "$@"`
+		}
+		writeScriptCommand(g, lines, req.body)
+	}
+
+	if !mode.grpc() {
+		return
+	}
+	grpcURL, grpcFlags := "localhost:50051", "-plaintext"
+	if len(environments) > 0 {
+		grpcURL, grpcFlags = environments[0].GRPCURL, s.grpcurlFlags(environments[0])
+	}
+	for _, f := range protoFiles {
+		for _, service := range f.Services {
+			prefix := s.collectionPrefix(f, service)
+			for _, method := range service.Methods {
+				filename := prefix + "scripts/" + s.grpcFolderName(s.methodFolder(service, method)) + "/" + s.requestFileStem(method, "grpc") + ".sh"
+				g := gen.NewGeneratedFile(filename, "")
+
+				var metadata [][2]string
+				for _, md := range s.grpcMetadata {
+					metadata = append(metadata, [2]string{strings.ToLower(md.key), s.metadataValue(md)})
+				}
+				authHeaders, _ := s.authParams(s.exportAuthMode(method))
+				for _, header := range authHeaders {
+					metadata = append(metadata, [2]string{strings.ToLower(header[0]), header[1]})
+				}
+				texts := []string{s.varRef("grpc_url")}
+				for _, md := range metadata {
+					texts = append(texts, md[1])
+				}
+				writeScriptHeader(g, s.requestName(method, "grpc"), method, referencedVariables(texts, map[string]string{s.varName("grpc_url"): grpcURL}))
+
+				lines := []string{`grpcurl ${GRPCURL_FLAGS:-` + grpcFlags + `}`}
+				for _, md := range metadata {
+					lines = append(lines, `  -H "`+md[0]+": "+shellVars(md[1])+`"`)
+				}
+				lines = append(lines, `  -d @ "$@" "`+shellVars(s.varRef("grpc_url"))+`" `+grpcgen.RPCName(method)+` <<'JSON'`)
+				writeScriptCommand(g, lines, s.exampleJSON(method, method.Input))
+			}
+		}
+	}
+}
+
+// grpcurlFlags returns the default grpcurl flags for the gRPC endpoint of an
+// environment
+func (s *state) grpcurlFlags(env envgen.Environment) string {
+	tls := grpcgen.TLS{Enabled: env.GRPCTLS()}
+	if s.mtlsEnabled {
+		tls = grpcgen.TLS{Enabled: true, CertFile: s.mtlsCertPath, KeyFile: s.mtlsKeyPath}
+	}
+	return grpcgen.GrpcurlFlags(tls)
+}
+
+// writeScriptHeader writes the shebang and comments of a request script, and
+// the defaults of the variables it reads from the environment
+func writeScriptHeader(g *protogen.GeneratedFile, name string, method *protogen.Method, vars [][2]string) {
+	g.P("#!/usr/bin/env bash")
+	g.P("# ", name)
+	g.P("# ", grpcgen.RPCName(method))
+	g.P("set -euo pipefail")
+	g.P()
+	for _, v := range vars {
+		g.P(shellVarName(v[0]), `="${`, shellVarName(v[0]), ":-", shellDoubleQuoted(v[1]), `}"`)
+	}
+	if len(vars) > 0 {
+		g.P()
+	}
+}
+
+// writeScriptCommand writes a command split over continuation lines, followed
+// by the here-document of its body, if any
+func writeScriptCommand(g *protogen.GeneratedFile, lines []string, body string) {
+	for i, line := range lines {
+		if i < len(lines)-1 {
+			line += ` \`
+		}
+		g.P(line)
+	}
+	if body != "" {
+		g.P(body)
+		g.P("JSON")
+	}
+}
+
+// shellDoubleQuoted escapes a value for a double-quoted shell string
+func shellDoubleQuoted(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`").Replace(value)
+}
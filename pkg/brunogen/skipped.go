@@ -0,0 +1,44 @@
+package brunogen
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/grpcgen"
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// skippedMethod is a request left out of the collection, with why, as listed
+// in stats.json
+type skippedMethod struct {
+	File   string `json:"file"`
+	Method string `json:"method"`
+	Reason string `json:"reason"`
+}
+
+// skipMethod records that a request of a method was not generated
+func (s *state) skipMethod(method *protogen.Method, reason string) {
+	s.tracef("method %s: skipped, %s", grpcgen.RPCName(method), reason)
+	s.skippedMethods = append(s.skippedMethods, skippedMethod{
+		File:   method.Desc.ParentFile().Path(),
+		Method: grpcgen.RPCName(method),
+		Reason: reason,
+	})
+}
+
+// reportSkipped writes a summary of the skipped requests, so coverage gaps
+// show up when generating rather than when a request is missing later.
+// Methods left out by include and exclude options are not listed.
+func (s *state) reportSkipped(w io.Writer) {
+	if w == nil || len(s.skippedMethods) == 0 {
+		return
+	}
+	requests := "requests"
+	if len(s.skippedMethods) == 1 {
+		requests = "request"
+	}
+	fmt.Fprintf(w, "protoc-gen-bruno: skipped %d %s:\n", len(s.skippedMethods), requests)
+	for _, skipped := range s.skippedMethods {
+		fmt.Fprintf(w, "  %s: %s: %s\n", skipped.File, skipped.Method, skipped.Reason)
+	}
+}
@@ -0,0 +1,138 @@
+package brunogen
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/eugene-bert/protoc-gen-bruno/pkg/brunogen/internal/naming"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// collectionSplit is the sub-collection a service is moved to when its
+// collection exceeds max_collection_requests
+type collectionSplit struct {
+	prefix string
+	name   string
+}
+
+// serviceRequestCount returns the number of requests generated for a service
+func (s *state) serviceRequestCount(service *protogen.Service, mode generationMode) int {
+	count := 0
+	for _, method := range service.Methods {
+		if mode.http() && s.hasHTTPRule(method) {
+			count++
+		}
+		if mode.grpc() {
+			count++
+		}
+	}
+	return count
+}
+
+// joinPrefix joins the non-empty parts of a collection prefix with underscores,
+// e.g. billing_apis_acme_billing_v1/, or returns "" for the root collection
+func joinPrefix(parts ...string) string {
+	var kept []string
+	for _, part := range parts {
+		if part != "" {
+			kept = append(kept, part)
+		}
+	}
+	if len(kept) == 0 {
+		return ""
+	}
+	return naming.SanitizeFile(strings.Join(kept, "_")) + "/"
+}
+
+// findCollectionSplits splits the collections with more requests than
+// max_collection_requests, since Bruno slows down on very large collections.
+// Their services are grouped into a sub-collection per package, and packages
+// still too large into a sub-collection per service. A single service is never
+// split.
+func (s *state) findCollectionSplits(protoFiles []*protogen.File, mode generationMode) {
+	s.collectionSplits = map[protoreflect.FullName]collectionSplit{}
+	if s.maxCollectionRequests <= 0 {
+		return
+	}
+
+	// Group the services by configured collection, then by package
+	collections := make(map[string]map[string][]*protogen.Service)
+	for _, f := range protoFiles {
+		for _, service := range f.Services {
+			prefix := s.configuredCollectionPrefix(f, service)
+			if collections[prefix] == nil {
+				collections[prefix] = make(map[string][]*protogen.Service)
+			}
+			pkg := string(f.Desc.Package())
+			collections[prefix][pkg] = append(collections[prefix][pkg], service)
+		}
+	}
+
+	for prefix, packages := range collections {
+		count, serviceCount := 0, 0
+		for _, services := range packages {
+			for _, service := range services {
+				count += s.serviceRequestCount(service, mode)
+				serviceCount++
+			}
+		}
+		if count <= s.maxCollectionRequests || serviceCount == 1 {
+			continue
+		}
+
+		parent := strings.TrimSuffix(prefix, "/")
+		pkgs := make([]string, 0, len(packages))
+		for pkg := range packages {
+			pkgs = append(pkgs, pkg)
+		}
+		sort.Strings(pkgs)
+		for _, pkg := range pkgs {
+			services := packages[pkg]
+			// Collections of a single package go straight to per-service splits
+			pkgParent := parent
+			if len(packages) > 1 {
+				pkgParent = strings.TrimSuffix(joinPrefix(parent, strings.ReplaceAll(pkg, ".", "_")), "/")
+			}
+
+			pkgCount := 0
+			for _, service := range services {
+				pkgCount += s.serviceRequestCount(service, mode)
+			}
+			for _, service := range services {
+				switch {
+				case (pkgCount <= s.maxCollectionRequests || len(services) == 1) && pkg == "":
+					// Services without a package stay in the collection
+				case pkgCount <= s.maxCollectionRequests || len(services) == 1:
+					s.collectionSplits[service.Desc.FullName()] = collectionSplit{
+						prefix: joinPrefix(pkgParent),
+						name:   naming.PackageTitle(pkg) + " API",
+					}
+				case pkgParent == "":
+					// Name the service collection after its package too, so it
+					// stays unique
+					s.collectionSplits[service.Desc.FullName()] = collectionSplit{
+						prefix: joinPrefix(strings.ReplaceAll(pkg, ".", "_"), naming.SnakeCase(service.GoName)),
+						name:   service.GoName + " API",
+					}
+				default:
+					s.collectionSplits[service.Desc.FullName()] = collectionSplit{
+						prefix: joinPrefix(pkgParent, naming.SnakeCase(service.GoName)),
+						name:   service.GoName + " API",
+					}
+				}
+			}
+		}
+	}
+}
+
+// splitCollectionName returns the name of the sub-collection written under a
+// prefix by findCollectionSplits
+func (s *state) splitCollectionName(prefix string) (string, bool) {
+	for _, split := range s.collectionSplits {
+		if split.prefix == prefix {
+			return split.name, true
+		}
+	}
+	return "", false
+}
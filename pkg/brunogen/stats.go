@@ -0,0 +1,67 @@
+package brunogen
+
+import (
+	"encoding/json"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// serviceStats counts the requests generated for a service in stats.json
+type serviceStats struct {
+	Service string `json:"service"`
+	File    string `json:"file"`
+	Methods int    `json:"methods"`
+	HTTP    int    `json:"http"`
+	GRPC    int    `json:"grpc"`
+	Skipped int    `json:"skipped"`
+}
+
+// generateStats writes a stats.json at the root of the output summarizing the
+// generation: the requests per service, HTTP and gRPC totals, and the skipped
+// methods, so API tooling coverage can be tracked across repositories
+func (s *state) generateStats(gen *protogen.Plugin, protoFiles []*protogen.File) error {
+	services := []*serviceStats{}
+	byName := make(map[string]*serviceStats)
+	for _, f := range protoFiles {
+		for _, service := range f.Services {
+			counts := &serviceStats{Service: string(service.Desc.FullName()), File: f.Desc.Path(), Methods: len(service.Methods)}
+			services = append(services, counts)
+			byName[counts.Service] = counts
+		}
+	}
+
+	var httpCount, grpcCount int
+	for _, requests := range s.manifestRequests {
+		for _, request := range requests {
+			counts := byName[request.Service]
+			if request.Protocol == "http" {
+				counts.HTTP++
+				httpCount++
+			} else {
+				counts.GRPC++
+				grpcCount++
+			}
+		}
+	}
+	skipped := []skippedMethod{}
+	for _, method := range s.skippedMethods {
+		service, _, _ := strings.Cut(method.Method, "/")
+		byName[service].Skipped++
+		skipped = append(skipped, method)
+	}
+
+	content, err := json.MarshalIndent(struct {
+		Requests int             `json:"requests"`
+		HTTP     int             `json:"http"`
+		GRPC     int             `json:"grpc"`
+		Services []*serviceStats `json:"services"`
+		Skipped  []skippedMethod `json:"skipped"`
+	}{httpCount + grpcCount, httpCount, grpcCount, services, skipped}, "", "  ")
+	if err != nil {
+		return err
+	}
+	g := gen.NewGeneratedFile("stats.json", "")
+	g.P(string(content))
+	return nil
+}
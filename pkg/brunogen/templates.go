@@ -0,0 +1,147 @@
+package brunogen
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// Kinds of generated files that can be rendered through a template, named after
+// their template file in template_dir, e.g. request.bru.tmpl
+var templateKinds = []string{"request.bru", "folder.bru", "collection.bru", "environment.bru", "bruno.json"}
+
+// templateFile is the data of a template: the generated file, with its .bru
+// blocks or decoded JSON
+type templateFile struct {
+	// Path is the path of the file in the collection
+	Path string
+	// Content is the generated content of the file
+	Content string
+	// Blocks are the top-level blocks of .bru files
+	Blocks []templateBlock
+	// Config is the decoded bruno.json
+	Config map[string]any
+	// Request describes the request of request files, like manifest.json
+	Request *manifestRequest
+	// Environment is the name of environment files
+	Environment string
+}
+
+// templateBlock is a top-level block of a .bru file, like "headers" or
+// "script:pre-request", with its lines
+type templateBlock struct {
+	Name  string
+	List  bool
+	Lines []string
+}
+
+// Block returns the block of a .bru file with the given name, or nil
+func (f templateFile) Block(name string) *templateBlock {
+	for i := range f.Blocks {
+		if f.Blocks[i].Name == name {
+			return &f.Blocks[i]
+		}
+	}
+	return nil
+}
+
+// templateFuncs are the functions available to templates besides the
+// text/template builtins
+var templateFuncs = template.FuncMap{
+	// bru formats a block back into the .bru format
+	"bru": func(block templateBlock) string {
+		return strings.TrimSuffix(formatBru([]bruBlock{{name: block.Name, list: block.List, lines: block.Lines}}), "\n")
+	},
+	// json formats a value as indented JSON
+	"json": func(v any) (string, error) {
+		content, err := json.MarshalIndent(v, "", "  ")
+		return string(content), err
+	},
+}
+
+// loadTemplates parses the templates found in template_dir. Kinds without a
+// template are generated as usual.
+func (s *state) loadTemplates(dir string) error {
+	s.templates = nil
+	if dir == "" {
+		return nil
+	}
+	s.templates = make(map[string]*template.Template)
+	for _, kind := range templateKinds {
+		name := kind + ".tmpl"
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(string(content))
+		if err != nil {
+			return err
+		}
+		s.templates[kind] = tmpl
+	}
+	return nil
+}
+
+// templateKind returns the kind of template a generated file is rendered with,
+// or "" for files generated as is, like scripts and READMEs
+func templateKind(name string) string {
+	base := path.Base(name)
+	switch {
+	case base == "bruno.json", base == "folder.bru", base == "collection.bru":
+		return base
+	case path.Base(path.Dir(name)) == "environments" && strings.HasSuffix(base, ".bru"):
+		return "environment.bru"
+	case strings.HasSuffix(base, ".bru"):
+		return "request.bru"
+	}
+	return ""
+}
+
+// applyTemplates renders the generated files through the templates of
+// template_dir, so teams can customize the boilerplate of their collections
+func (s *state) applyTemplates(resp *pluginpb.CodeGeneratorResponse) error {
+	if len(s.templates) == 0 {
+		return nil
+	}
+	for _, file := range resp.File {
+		kind := templateKind(file.GetName())
+		tmpl, ok := s.templates[kind]
+		if !ok {
+			continue
+		}
+		data := templateFile{Path: file.GetName(), Content: file.GetContent()}
+		if kind == "bruno.json" {
+			if err := json.Unmarshal([]byte(file.GetContent()), &data.Config); err != nil {
+				return err
+			}
+		} else {
+			for _, block := range parseBru(file.GetContent()) {
+				data.Blocks = append(data.Blocks, templateBlock{Name: block.name, List: block.list, Lines: block.lines})
+			}
+		}
+		if request, ok := s.templateRequests[file.GetName()]; ok {
+			data.Request = &request
+		}
+		if kind == "environment.bru" {
+			data.Environment = strings.TrimSuffix(path.Base(file.GetName()), ".bru")
+		}
+
+		var content strings.Builder
+		if err := tmpl.Execute(&content, data); err != nil {
+			return err
+		}
+		file.Content = proto.String(content.String())
+	}
+	return nil
+}
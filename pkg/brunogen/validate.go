@@ -0,0 +1,217 @@
+package brunogen
+
+import (
+	"math"
+	"strconv"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Field numbers of the (buf.validate.field) option and of the FieldRules fields
+// used for response tests
+const (
+	validateFieldOption protowire.Number = 1159
+
+	validateRequired protowire.Number = 25
+	validateString   protowire.Number = 14
+	validateRepeated protowire.Number = 18
+)
+
+// numericRules maps the FieldRules field of each numeric type to the kind of
+// its bounds
+var numericRules = map[protowire.Number]protoreflect.Kind{
+	1:  protoreflect.FloatKind,
+	2:  protoreflect.DoubleKind,
+	3:  protoreflect.Int32Kind,
+	4:  protoreflect.Int64Kind,
+	5:  protoreflect.Uint32Kind,
+	6:  protoreflect.Uint64Kind,
+	7:  protoreflect.Sint32Kind,
+	8:  protoreflect.Sint64Kind,
+	9:  protoreflect.Fixed32Kind,
+	10: protoreflect.Fixed64Kind,
+	11: protoreflect.Sfixed32Kind,
+	12: protoreflect.Sfixed64Kind,
+}
+
+// wireField is a field decoded from protobuf wire data
+type wireField struct {
+	num   protowire.Number
+	typ   protowire.Type
+	value uint64
+	bytes []byte
+}
+
+// wireFields decodes the fields of a message, stopping at malformed data
+func wireFields(b []byte) []wireField {
+	var fields []wireField
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			break
+		}
+		b = b[n:]
+
+		field := wireField{num: num, typ: typ}
+		switch typ {
+		case protowire.VarintType:
+			field.value, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed32Type:
+			var v uint32
+			v, n = protowire.ConsumeFixed32(b)
+			field.value = uint64(v)
+		case protowire.Fixed64Type:
+			field.value, n = protowire.ConsumeFixed64(b)
+		case protowire.BytesType:
+			field.bytes, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			break
+		}
+		b = b[n:]
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// fieldValidationRules returns the (buf.validate.field) rules of a field, or
+// nil when it has none. Like the bruno.v1 options, the extension has no
+// generated Go type and is decoded from the unknown fields.
+func fieldValidationRules(field *protogen.Field) []wireField {
+	opts := field.Desc.Options()
+	if opts == nil {
+		return nil
+	}
+	var rules []wireField
+	for _, f := range wireFields(opts.ProtoReflect().GetUnknown()) {
+		if f.num == validateFieldOption && f.typ == protowire.BytesType {
+			// Repeated occurrences of a message option are merged
+			rules = append(rules, wireFields(f.bytes)...)
+		}
+	}
+	return rules
+}
+
+// numericBound formats a numeric rule value as a JavaScript number
+func numericBound(kind protoreflect.Kind, f wireField) string {
+	switch kind {
+	case protoreflect.FloatKind:
+		return strconv.FormatFloat(float64(math.Float32frombits(uint32(f.value))), 'g', -1, 32)
+	case protoreflect.DoubleKind:
+		return strconv.FormatFloat(math.Float64frombits(f.value), 'g', -1, 64)
+	case protoreflect.Sint32Kind, protoreflect.Sint64Kind:
+		return strconv.FormatInt(protowire.DecodeZigZag(f.value), 10)
+	case protoreflect.Uint32Kind, protoreflect.Uint64Kind, protoreflect.Fixed32Kind, protoreflect.Fixed64Kind:
+		return strconv.FormatUint(f.value, 10)
+	case protoreflect.Sfixed32Kind:
+		return strconv.FormatInt(int64(int32(f.value)), 10)
+	default:
+		return strconv.FormatInt(int64(f.value), 10)
+	}
+}
+
+// validationExpectations returns the chai expectations checking a response
+// field against its rules. Proto3 JSON omits fields holding default values, so
+// absent fields are checked as their default.
+func validationExpectations(field *protogen.Field) []string {
+	jsonName := field.Desc.JSONName()
+	value := "res.getBody()?." + jsonName
+	var lines []string
+	for _, rule := range fieldValidationRules(field) {
+		switch {
+		case rule.num == validateRequired && protowire.DecodeBool(rule.value):
+			lines = append(lines, `expect(`+value+`, "`+jsonName+` is required").to.exist;`)
+		case rule.num == validateString && !field.Desc.IsList():
+			lines = append(lines, stringExpectations(value+` ?? ""`, jsonName, wireFields(rule.bytes))...)
+		case rule.num == validateRepeated && field.Desc.IsList():
+			for _, r := range wireFields(rule.bytes) {
+				switch r.num {
+				case 1: // min_items
+					lines = append(lines, `expect((`+value+` ?? []).length, "`+jsonName+` items").to.be.at.least(`+strconv.FormatUint(r.value, 10)+`);`)
+				case 2: // max_items
+					lines = append(lines, `expect((`+value+` ?? []).length, "`+jsonName+` items").to.be.at.most(`+strconv.FormatUint(r.value, 10)+`);`)
+				}
+			}
+		case numericRules[rule.num] != 0 && !field.Desc.IsList():
+			kind := numericRules[rule.num]
+			for _, r := range wireFields(rule.bytes) {
+				// Int64 values are strings in proto3 JSON
+				actual := `Number(` + value + ` ?? 0)`
+				switch r.num {
+				case 2: // lt
+					lines = append(lines, `expect(`+actual+`, "`+jsonName+`").to.be.below(`+numericBound(kind, r)+`);`)
+				case 3: // lte
+					lines = append(lines, `expect(`+actual+`, "`+jsonName+`").to.be.at.most(`+numericBound(kind, r)+`);`)
+				case 4: // gt
+					lines = append(lines, `expect(`+actual+`, "`+jsonName+`").to.be.above(`+numericBound(kind, r)+`);`)
+				case 5: // gte
+					lines = append(lines, `expect(`+actual+`, "`+jsonName+`").to.be.at.least(`+numericBound(kind, r)+`);`)
+				}
+			}
+		}
+	}
+	return lines
+}
+
+// stringExpectations returns the expectations of the buf.validate StringRules
+// of a field
+func stringExpectations(value string, jsonName string, rules []wireField) []string {
+	var lines []string
+	length := `[...(` + value + `)].length`
+	for _, r := range rules {
+		switch r.num {
+		case 19: // len
+			lines = append(lines, `expect(`+length+`, "`+jsonName+` length").to.equal(`+strconv.FormatUint(r.value, 10)+`);`)
+		case 2: // min_len
+			lines = append(lines, `expect(`+length+`, "`+jsonName+` length").to.be.at.least(`+strconv.FormatUint(r.value, 10)+`);`)
+		case 3: // max_len
+			lines = append(lines, `expect(`+length+`, "`+jsonName+` length").to.be.at.most(`+strconv.FormatUint(r.value, 10)+`);`)
+		case 6: // pattern
+			lines = append(lines, `expect(`+value+`, "`+jsonName+`").to.match(new RegExp(`+strconv.Quote(string(r.bytes))+`));`)
+		case 7: // prefix
+			lines = append(lines, `expect((`+value+`).startsWith(`+strconv.Quote(string(r.bytes))+`), "`+jsonName+` prefix").to.be.true;`)
+		case 8: // suffix
+			lines = append(lines, `expect((`+value+`).endsWith(`+strconv.Quote(string(r.bytes))+`), "`+jsonName+` suffix").to.be.true;`)
+		case 9: // contains
+			lines = append(lines, `expect(`+value+`, "`+jsonName+`").to.include(`+strconv.Quote(string(r.bytes))+`);`)
+		case 12: // email
+			if protowire.DecodeBool(r.value) {
+				lines = append(lines, `expect(`+value+`, "`+jsonName+` is an email address").to.match(/^[^@\s]+@[^@\s]+$/);`)
+			}
+		case 17: // uri
+			if protowire.DecodeBool(r.value) {
+				lines = append(lines, `expect(() => new URL(`+value+`), "`+jsonName+` is a URI").to.not.throw();`)
+			}
+		case 22: // uuid
+			if protowire.DecodeBool(r.value) {
+				lines = append(lines, `expect(`+value+`, "`+jsonName+` is a UUID").to.match(/^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$/i);`)
+			}
+		}
+	}
+	return lines
+}
+
+// validationTestScript returns a test per output field checking that the
+// response honors the field's buf.validate rules, or nil when no field has any
+func validationTestScript(method *protogen.Method) []string {
+	var lines []string
+	for _, field := range method.Output.Fields {
+		expectations := validationExpectations(field)
+		if len(expectations) == 0 {
+			continue
+		}
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, `test("`+field.Desc.JSONName()+` honors its validation rules", function () {`)
+		for _, expectation := range expectations {
+			lines = append(lines, "  "+expectation)
+		}
+		lines = append(lines, "});")
+	}
+	return lines
+}
@@ -0,0 +1,89 @@
+package brunogen
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// maxVerifyReports caps the files listed when verify fails
+const maxVerifyReports = 20
+
+// verifyOutput compares the generated files with the ones in the output
+// directory instead of writing them, so CI can fail when a checked-in
+// collection is stale. Files are reported as changed, missing, or left over
+// in a generated folder without being generated anymore. generated lists all
+// the generated files, including the ones write_mode=skip-existing dropped.
+func (s *state) verifyOutput(resp *pluginpb.CodeGeneratorResponse, generated map[string]bool) error {
+	var reports []string
+	folders := make(map[string]bool)
+	for name := range generated {
+		folders[path.Dir(name)] = true
+	}
+	for _, file := range resp.File {
+		existing, err := os.ReadFile(filepath.Join(s.outDir, filepath.FromSlash(file.GetName())))
+		if errors.Is(err, fs.ErrNotExist) {
+			reports = append(reports, "missing: "+file.GetName())
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if line, have, want, ok := firstDifference(normalizeContent(string(existing)), file.GetContent()); ok {
+			reports = append(reports, fmt.Sprintf("changed: %s, line %d: have %q, want %q", file.GetName(), line, have, want))
+		}
+	}
+
+	// Requests of removed methods are left behind in the generated folders
+	for folder := range folders {
+		entries, err := os.ReadDir(filepath.Join(s.outDir, filepath.FromSlash(folder)))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := path.Join(folder, entry.Name())
+			if !entry.IsDir() && strings.HasSuffix(name, ".bru") && !generated[name] {
+				reports = append(reports, "stale: "+name)
+			}
+		}
+	}
+
+	resp.File = nil
+	if len(reports) == 0 {
+		return nil
+	}
+	sort.Strings(reports)
+	count := len(reports)
+	if count > maxVerifyReports {
+		reports = append(reports[:maxVerifyReports], fmt.Sprintf("and %d more", count-maxVerifyReports))
+	}
+	return fmt.Errorf("the collection in %s is out of date (%d files), regenerate it:\n  %s", s.outDir, count, strings.Join(reports, "\n  "))
+}
+
+// firstDifference returns the first line, counted from 1, at which two file
+// contents differ, with the line of each
+func firstDifference(have string, want string) (int, string, string, bool) {
+	if have == want {
+		return 0, "", "", false
+	}
+	haveLines, wantLines := strings.Split(have, "\n"), strings.Split(want, "\n")
+	for i := 0; ; i++ {
+		var h, w string
+		if i < len(haveLines) {
+			h = haveLines[i]
+		}
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if h != w || i >= len(haveLines) || i >= len(wantLines) {
+			return i + 1, h, w, true
+		}
+	}
+}